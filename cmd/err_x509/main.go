@@ -0,0 +1,9 @@
+// Команда err_x509 добавляет skip-cert-verify к прокси в конфигах
+// Clash / Mihomo. Флаги описаны в README.md.
+package main
+
+import "github.com/13winged/err_x509/internal/cli"
+
+func main() {
+	cli.Main()
+}
@@ -0,0 +1,49 @@
+// Package errx509 — обработка конфигов Clash / Mihomo из своего кода:
+// то же, что делает команда err_x509 (cmd/err_x509), без флагов и без
+// завершения процесса. Fix добавляет skip-cert-verify и возвращает список
+// изменений, ParseProxies и EncodeProxies разбирают конфиг в список Proxy
+// и записывают обратно.
+package errx509
+
+import "github.com/13winged/err_x509/internal/cli"
+
+// Proxy — прокси из конфига: общие поля и все остальные в Extra.
+// ParseProxies и EncodeProxies сохраняют поля без потерь.
+type Proxy = cli.Proxy
+
+// ProxyChange — одно изменение поля прокси, которое вернул Fix
+type ProxyChange = cli.ProxyChange
+
+// Options — параметры Fix. Пустые поля — значения флагов по умолчанию.
+type Options = cli.Options
+
+// DefaultProxiesKeys — ключи секции прокси, которые ParseProxies ищет
+// по умолчанию: proxies и Proxy из конфигов subconverter. Команда без
+// флагов ищет только proxies, Proxy — с -subconverter.
+var DefaultProxiesKeys = []string{"proxies", "Proxy"}
+
+// Fix обрабатывает конфиг так же, как команда с флагами по умолчанию
+// и параметрами o, и возвращает новый текст. С o.Explain возвращает
+// и каждое изменение по полям, без Explain список равен nil. Ошибки в o
+// возвращаются как error; Fix можно вызывать из нескольких горутин.
+func Fix(content string, o Options) (string, []ProxyChange, error) {
+	return cli.Fix(content, o)
+}
+
+// ParseProxies разбирает конфиг и возвращает прокси в порядке следования
+// из секции под первым найденным ключом keys (без keys — DefaultProxiesKeys,
+// как -subconverter в командной строке). Файл, который сам является списком
+// прокси (proxy-provider), разбирается целиком. Конфиг без секции прокси —
+// пустой список.
+func ParseProxies(content string, keys ...string) ([]Proxy, error) {
+	if len(keys) == 0 {
+		keys = DefaultProxiesKeys
+	}
+	return cli.ParseProxies(content, keys...)
+}
+
+// EncodeProxies записывает прокси как конфиг с секцией key или, если key
+// пустой, как список прокси без ключа (файл proxy-provider)
+func EncodeProxies(proxies []Proxy, key string) (string, error) {
+	return cli.EncodeProxies(proxies, key)
+}
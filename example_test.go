@@ -0,0 +1,38 @@
+package errx509_test
+
+import (
+	"fmt"
+
+	errx509 "github.com/13winged/err_x509"
+)
+
+func ExampleParseProxies() {
+	proxies, err := errx509.ParseProxies(`all-proxies:
+  - {name: A, type: trojan, server: a.example.com, port: 443, password: p}
+`, "all-proxies")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, p := range proxies {
+		fmt.Println(p.Name, p.Type, p.Server, p.Port, p.Extra["password"])
+	}
+	// Output: A trojan a.example.com 443 p
+}
+
+func ExampleFix() {
+	content, changes, err := errx509.Fix("proxies:\n  - {name: A, type: trojan, server: a.example.com, port: 443}\n",
+		errx509.Options{Explain: true})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(content)
+	for _, c := range changes {
+		fmt.Println(c.Name, c.Action, c.Field, c.NewValue)
+	}
+	// Output:
+	// proxies:
+	//   - {name: A, type: trojan, server: a.example.com, port: 443, skip-cert-verify: true}
+	// A add skip-cert-verify true
+}
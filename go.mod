@@ -1,3 +1,8 @@
 module github.com/13winged/err_x509
 
 go 1.21
+
+require (
+	golang.org/x/text v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
+)
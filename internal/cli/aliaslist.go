@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Способы -alias-list
+const (
+	aliasListAnchor = "anchor" // поле добавляется в определение якоря
+	aliasListInline = "inline" // под ключом записывается измененная копия списка
+)
+
+// sectionAlias возвращает ссылку *name, если список прокси под одним из
+// ключей keys задан ссылкой на якорь (proxies: *all_proxies)
+func sectionAlias(doc *yaml.Node, keys []string) *yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if value := mappingValue(doc.Content[0], key); value != nil && value.Kind == yaml.AliasNode &&
+			value.Alias != nil && value.Alias.Kind == yaml.SequenceNode {
+			return value
+		}
+	}
+	return nil
+}
+
+// aliasedSection проверяет по тексту конфига, что список прокси задан
+// ссылкой на якорь. Такой список текстовым способом не найти: конфиг
+// обрабатывается через разбор YAML.
+func aliasedSection(content string, keys []string) bool {
+	doc, err := loadDocument(content)
+	return err == nil && sectionAlias(doc, keys) != nil
+}
+
+// proxyList возвращает список прокси документа, который будет изменен.
+// С -alias-list inline ссылка под ключом заменяется копией списка:
+// меняется копия, а определение якоря и другие ссылки на него остаются
+// как были.
+func (o *options) proxyList(doc *yaml.Node) *yaml.Node {
+	if o.AliasList == aliasListInline {
+		if alias := sectionAlias(doc, o.proxiesKeys()); alias != nil {
+			*alias = *copyNode(alias.Alias)
+		}
+	}
+	return proxiesNode(doc, o.proxiesKeys())
+}
+
+// copyNode возвращает глубокую копию узла. Якоря в копии не повторяются,
+// ссылки указывают на те же узлы, что в оригинале.
+func copyNode(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Anchor = ""
+	if node.Kind == yaml.AliasNode {
+		return &c
+	}
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+// aliasWarning — предупреждение о списке прокси, заданном ссылкой
+func aliasWarning(opts *options, alias *yaml.Node) warning {
+	target := alias.Alias
+	message := fmt.Sprintf("список прокси задан ссылкой *%s: поле добавлено в определение якоря &%s (строка %d), оно изменится и для всех ссылок на него",
+		alias.Value, target.Anchor, target.Line)
+	if opts.AliasList == aliasListInline {
+		message = fmt.Sprintf("список прокси задан ссылкой *%s: ссылка заменена измененной копией списка, якорь &%s (строка %d) не изменен",
+			alias.Value, target.Anchor, target.Line)
+	}
+	return warning{
+		Kind:    warnAliasList,
+		Line:    alias.Line,
+		Value:   "*" + alias.Value,
+		Message: message,
+	}
+}
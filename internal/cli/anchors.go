@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"reflect"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mergeAliasPattern — ключ слияния с ссылками на якоря: <<: *a или <<: [*a, *b]
+var mergeAliasPattern = regexp.MustCompile(`<<\s*:\s*(\*[^\s,\]\}]+|\[[^\]]*\])`)
+
+// aliasNamePattern — имя якоря в ссылке *name
+var aliasNamePattern = regexp.MustCompile(`\*([^\s,\]\}]+)`)
+
+// fieldAnchors находит якоря (&name) отображений, в которых задано поле
+// field, в порядке появления в документе
+func fieldAnchors(doc *yaml.Node, field string) []*yaml.Node {
+	var anchors []*yaml.Node
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Anchor != "" && node.Kind == yaml.MappingNode && mappingValue(node, field) != nil {
+			anchors = append(anchors, node)
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return anchors
+}
+
+// fieldAnchorNames возвращает имена якорей, задающих поле field, и первый
+// из них, пригодный для подключения к прокси вместо поля со значением
+// value (-use-anchor, isMergeableAnchor). Если документ не разбирается,
+// якорей нет.
+func fieldAnchorNames(content, field, value string) (names []string, mergeable string) {
+	doc, err := loadDocument(content)
+	if err != nil {
+		return nil, ""
+	}
+	for _, node := range fieldAnchors(doc, field) {
+		names = append(names, node.Anchor)
+		if mergeable == "" && isMergeableAnchor(node, field, value) {
+			mergeable = node.Anchor
+		}
+	}
+	return names, mergeable
+}
+
+// isMergeableAnchor проверяет, что якорь можно подключить вместо поля
+// field со значением value: в нем нет ничего, кроме этого поля, и значение
+// то же самое. Иначе вместе с якорем к прокси попали бы чужие поля
+// (udp, name, server) или другое значение, например skip-cert-verify: false.
+func isMergeableAnchor(node *yaml.Node, field, value string) bool {
+	if len(node.Content) != 2 || node.Content[0].Value != field || node.Content[1].Kind != yaml.ScalarNode {
+		return false
+	}
+	var want, got interface{}
+	if yaml.Unmarshal([]byte(value), &want) != nil || node.Content[1].Decode(&got) != nil {
+		return false
+	}
+	return reflect.DeepEqual(want, got)
+}
+
+// mergesAnyAnchor проверяет, подключает ли запись прокси через <<
+// один из якорей из списка
+func mergesAnyAnchor(text string, anchors []string) bool {
+	if len(anchors) == 0 {
+		return false
+	}
+	for _, m := range mergeAliasPattern.FindAllStringSubmatch(text, -1) {
+		for _, alias := range aliasNamePattern.FindAllStringSubmatch(m[1], -1) {
+			for _, anchor := range anchors {
+				if alias[1] == anchor {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// hasMergedField проверяет, получает ли отображение поле field через
+// ключ слияния <<
+func hasMergedField(mapping *yaml.Node, field string) bool {
+	merge := mappingValue(mapping, "<<")
+	if merge == nil {
+		return false
+	}
+	sources := []*yaml.Node{merge}
+	if merge.Kind == yaml.SequenceNode {
+		sources = merge.Content
+	}
+	for _, source := range sources {
+		if source.Kind == yaml.AliasNode {
+			source = source.Alias
+		}
+		if mappingValue(source, field) != nil || (source != nil && hasMergedField(source, field)) {
+			return true
+		}
+	}
+	return false
+}
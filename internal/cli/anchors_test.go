@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIsMergeableAnchor(t *testing.T) {
+	cases := []struct {
+		anchor string
+		value  string
+		want   bool
+	}{
+		{"&insecure { skip-cert-verify: true }", "true", true},
+		{"&insecure { skip-cert-verify: True }", "true", true},
+		{"&insecure { skip-cert-verify: true }", "false", false},
+		{"&insecure { skip-cert-verify: \"true\" }", "true", false},
+		{"&strict { skip-cert-verify: false, udp: true, tfo: true }", "false", false},
+		{"&common { skip-cert-verify: true, udp: true }", "true", false},
+		{"&proxy { name: a, server: a.com, skip-cert-verify: true }", "true", false},
+		{"&nested { skip-cert-verify: { enabled: true } }", "true", false},
+	}
+	for _, c := range cases {
+		names, mergeable := fieldAnchorNames("x: "+c.anchor+"\n", "skip-cert-verify", c.value)
+		if len(names) != 1 {
+			t.Errorf("%s: найдено якорей %d, want 1", c.anchor, len(names))
+			continue
+		}
+		if got := mergeable != ""; got != c.want {
+			t.Errorf("%s с -value %s: подключается = %v, want %v", c.anchor, c.value, got, c.want)
+		}
+	}
+}
+
+func TestUseAnchorOnlyExactMatch(t *testing.T) {
+	for _, c := range []struct {
+		file   string
+		args   []string
+		anchor string
+	}{
+		{"testdata/anchor.yaml", nil, "insecure"},
+		{"testdata/anchor.yaml", []string{"-value", "false"}, ""},
+		{"testdata/anchor_strict.yaml", nil, ""},
+		{"testdata/anchor_strict.yaml", []string{"-value", "false"}, ""},
+	} {
+		data, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, engine := range [][]string{nil, {"-keep-fields", "name,type,server,port,uuid,password"}} {
+			args := append(append([]string{"-use-anchor"}, c.args...), engine...)
+			res, err := processContent(string(data), testOptions(t, args...))
+			if err != nil {
+				t.Fatalf("%s %v: %v", c.file, args, err)
+			}
+			if res.Anchor != c.anchor {
+				t.Errorf("%s %v: подключен якорь %q, want %q", c.file, args, res.Anchor, c.anchor)
+			}
+			if res.Modified != 1 {
+				t.Errorf("%s %v: добавлено %d, want 1", c.file, args, res.Modified)
+			}
+			server2 := res.Content[strings.Index(res.Content, "Server2"):]
+			if c.anchor == "" && !strings.Contains(server2, "skip-cert-verify: ") {
+				t.Errorf("%s %v: Server2 не получил поле:\n%s", c.file, args, res.Content)
+			}
+		}
+	}
+}
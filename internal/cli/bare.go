@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// isProxyList проверяет, что узел — список прокси без ключа proxies:
+// непустой список словарей, хотя бы у одного из которых есть name и
+// type или server. Так выглядят файлы proxy-provider.
+func isProxyList(node *yaml.Node) bool {
+	if node.Kind != yaml.SequenceNode || len(node.Content) == 0 {
+		return false
+	}
+	proxyLike := false
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			return false
+		}
+		if mappingValue(item, "name") != nil && (mappingValue(item, "type") != nil || mappingValue(item, "server") != nil) {
+			proxyLike = true
+		}
+	}
+	return proxyLike
+}
+
+// bareList проверяет, что конфиг — список прокси без ключа proxies.
+// Первая значимая строка — элемент списка "-"; обрезанный файл, который
+// не разбирается, тоже считается списком, и о нем предупредит проверка
+// записей.
+func bareList(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "%") {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "---") {
+			return false
+		}
+		break
+	}
+	doc, err := loadDocument(content)
+	if err != nil {
+		return true
+	}
+	return len(doc.Content) > 0 && isProxyList(doc.Content[0])
+}
@@ -0,0 +1,656 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// batchFile — файл пакетного режима и пути для результата и резервной копии
+type batchFile struct {
+	Input  string
+	Rel    string // путь относительно папки -dir
+	Output string
+	Backup string
+	Copy   bool // -passthrough: не конфиг, копируется как есть
+}
+
+// batchSummary — итог пакетной обработки в режиме -json
+type batchSummary struct {
+	Files       []jsonSummary  `json:"files"`
+	Flavor      string         `json:"flavor"`
+	Modified    int            `json:"modified"`
+	AlreadyHas  int            `json:"already_has"`
+	Total       int            `json:"total"`
+	Protected   int            `json:"protected,omitempty"`
+	Skipped     int            `json:"skipped,omitempty"`
+	Filtered    int            `json:"filtered,omitempty"`
+	NoRequired  int            `json:"no_required_field,omitempty"` // нет поля из -require-field
+	ServerOut   int            `json:"server_filtered,omitempty"`   // -include-server / -exclude-server
+	NoTLS       int            `json:"no_tls,omitempty"`            // прокси без TLS (-only-type-tls)
+	Removed     int            `json:"removed,omitempty"`
+	Deduped     int            `json:"deduped,omitempty"` // удалено повторов (-dedup-by)
+	Transformed map[string]int `json:"transformed,omitempty"`
+	DedupedKeys int            `json:"deduped_keys,omitempty"` // прокси, у которых убраны повторы полей (-dedup-keys)
+	Bools       int            `json:"normalized_bools,omitempty"`
+	Blocks      int            `json:"markdown_blocks,omitempty"`
+	BlocksFixed int            `json:"markdown_blocks_fixed,omitempty"`
+	Copied      int            `json:"copied,omitempty"`
+	NotConfig   int            `json:"not_config,omitempty"`
+	Unchanged   int            `json:"unchanged,omitempty"`
+	TooDeep     int            `json:"too_deep,omitempty"` // глубже -max-depth
+	Unwritable  int            `json:"unwritable,omitempty"`
+	Failed      int            `json:"failed"`
+	NotReached  int            `json:"not_reached,omitempty"` // не обработаны после первой ошибки
+}
+
+// isFixedName проверяет, что файл — результат предыдущей обработки
+func isFixedName(name string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(name, filepath.Ext(name)), ".fixed")
+}
+
+// isBackupName проверяет, что файл — резервная копия конфига
+func isBackupName(name string) bool {
+	for _, suffix := range []string{backupSuffix("copy"), backupSuffix("patch")} {
+		if base := strings.TrimSuffix(name, suffix); base != name && (isYAMLName(base) || isMarkdownName(base)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isYAMLName проверяет расширение файла конфигурации
+func isYAMLName(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// isMarkdownName проверяет расширение markdown-файла
+func isMarkdownName(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".md" || ext == ".markdown"
+}
+
+// isInputName проверяет, что файл обрабатывается в пакетном режиме:
+// YAML всегда, markdown — с -input-format markdown или auto
+func isInputName(name string, opts *options) bool {
+	return isYAMLName(name) || (opts.InputFormat != "yaml" && isMarkdownName(name))
+}
+
+// newBatchFile вычисляет пути результата и резервной копии для файла path,
+// который лежит в папке root по относительному пути rel
+func newBatchFile(path, root, rel string, copyOnly bool, opts *options) batchFile {
+	file := batchFile{Input: path, Rel: rel, Copy: copyOnly}
+
+	// Из markdown с -output-format yaml записывается только YAML
+	outRel := rel
+	if !copyOnly && opts.OutputFormat == "yaml" && isMarkdownInput(path, opts) {
+		outRel = strings.TrimSuffix(rel, filepath.Ext(rel)) + ".yaml"
+	}
+	if opts.InPlace {
+		file.Output = path
+	} else if opts.OutDir != "" {
+		file.Output = filepath.Join(opts.OutDir, outRel)
+	} else {
+		ext := filepath.Ext(outRel)
+		file.Output = filepath.Join(root, strings.TrimSuffix(outRel, ext)+".fixed"+ext)
+	}
+	if opts.BackupDir != "" {
+		file.Backup = filepath.Join(opts.BackupDir, rel) + backupSuffix(opts.BackupFormat)
+	} else {
+		file.Backup = path + backupSuffix(opts.BackupFormat)
+	}
+	return file
+}
+
+// inputFiles возвращает файлы, переданные через -in и аргументами, в порядке
+// указания. Результаты и копии пишутся рядом с каждым файлом или в -out-dir
+// и -backup-dir под его именем (профили -profiles — под путем от папки
+// индекса), поэтому одинаковые имена там недопустимы.
+func inputFiles(opts *options) ([]batchFile, error) {
+	var files []batchFile
+	outputs := map[string]string{}
+	for _, path := range opts.Inputs {
+		root, rel := filepath.Dir(path), filepath.Base(path)
+		if opts.Profiles != "" {
+			// Профили показываются и раскладываются по -out-dir от папки индекса
+			root, rel = profileRoot(opts.Profiles, path)
+		}
+		file := newBatchFile(path, root, rel, false, opts)
+		if (opts.OutDir != "" || opts.BackupDir != "") && outputs[file.Rel] != "" {
+			return nil, fmt.Errorf("файлы %s и %s с одинаковым именем запишутся в один результат",
+				outputs[file.Rel], path)
+		}
+		outputs[file.Rel] = path
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// collectBatchFiles находит файлы конфигурации в папке -dir и вычисляет
+// для каждого пути результата и резервной копии. Второе значение — число
+// файлов глубже -max-depth: они не обрабатываются, но считаются.
+func collectBatchFiles(opts *options) ([]batchFile, int, error) {
+	// Папки с результатами и копиями внутри -dir не обходим
+	var skipDirs []string
+	for _, dir := range []string{opts.OutDir, opts.BackupDir} {
+		if dir != "" {
+			if abs, err := filepath.Abs(dir); err == nil {
+				skipDirs = append(skipDirs, abs)
+			}
+		}
+	}
+
+	var files []batchFile
+	tooDeep := 0
+	err := filepath.WalkDir(opts.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == opts.Dir {
+				return nil
+			}
+			if !opts.Recursive || strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if abs, err := filepath.Abs(path); err == nil {
+				for _, skip := range skipDirs {
+					if abs == skip {
+						return filepath.SkipDir
+					}
+				}
+			}
+			return nil
+		}
+		// Ссылки на файлы обрабатываются как сами файлы, если не задан -no-follow-symlinks
+		if d.Type()&fs.ModeSymlink != 0 {
+			if opts.NoFollowSymlinks {
+				sayf("⏭️  %s: символическая ссылка пропущена (-no-follow-symlinks)\n", path)
+				return nil
+			}
+			if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+		} else if !d.Type().IsRegular() {
+			return nil
+		}
+		// С -passthrough в папку результатов попадают и остальные файлы
+		// (кроме резервных копий предыдущих запусков)
+		copyOnly := !isInputName(d.Name(), opts) || isFixedName(d.Name())
+		if copyOnly && (!opts.Passthrough || isBackupName(d.Name())) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(opts.Dir, path)
+		if err != nil {
+			return err
+		}
+		if opts.MaxDepth >= 0 && strings.Count(rel, string(filepath.Separator)) > opts.MaxDepth {
+			tooDeep++
+			return nil
+		}
+		files = append(files, newBatchFile(path, opts.Dir, rel, copyOnly, opts))
+		return nil
+	})
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Rel < files[j].Rel })
+	return files, tooDeep, err
+}
+
+// runBatch обрабатывает все файлы конфигурации в папке -dir или файлы из -in
+// и аргументов и возвращает код выхода
+func runBatch(opts *options) int {
+	var files []batchFile
+	total := batchSummary{Flavor: opts.Flavor}
+	if opts.Dir != "" {
+		var err error
+		if files, total.TooDeep, err = collectBatchFiles(opts); err != nil {
+			sayf("❌ Ошибка обхода папки %s: %v\n", opts.Dir, err)
+			return 1
+		}
+		if len(files) == 0 {
+			sayf("⚠️  В папке %s не найдено файлов *.yaml / *.yml\n", opts.Dir)
+		}
+	} else {
+		var err error
+		if files, err = inputFiles(opts); err != nil {
+			sayf("❌ %v\n", err)
+			return 2
+		}
+	}
+
+	if !opts.DryRun {
+		files = writableFiles(files, &total)
+	}
+
+	var dirs []string
+	for _, file := range files {
+		dirs = append(dirs, targetDir(file.Output), targetDir(file.Backup))
+	}
+	cleanStaleTemps(dirs, opts.DryRun)
+
+	var state *runState
+	if opts.State != "" {
+		var err error
+		if state, err = loadState(opts.State, optionsFingerprint(flag.CommandLine)); err != nil {
+			sayf("❌ Ошибка чтения состояния -state: %v\n", err)
+			return 2
+		}
+	}
+
+	report := &proxyReport{}
+	script := &editScript{}
+	diffs := &diffArchive{}
+	bundle := &bundleArchive{}
+	for i, file := range files {
+		// С -state файлы, не изменившиеся с прошлого запуска, пропускаются
+		if state != nil && !opts.Force {
+			if data, err := os.ReadFile(file.Input); err == nil && state.unchanged(file, contentHash(data)) {
+				sayf("⏭️  %s: не изменился с прошлого запуска (-state)\n", file.Rel)
+				total.Unchanged++
+				continue
+			}
+		}
+
+		summary, ok := processBatchFile(file, opts, report, script, diffs, bundle)
+		if state != nil && summary.Written {
+			// Хэш берется после записи: с -in-place результат и есть входной файл
+			if data, err := os.ReadFile(file.Input); err == nil {
+				state.remember(file, contentHash(data))
+			}
+		}
+		total.Files = append(total.Files, summary)
+		total.Modified += summary.Modified
+		total.AlreadyHas += summary.AlreadyHas
+		total.Total += summary.Total
+		total.Protected += summary.Protected
+		total.Skipped += summary.Skipped
+		total.Filtered += summary.Filtered
+		total.NoRequired += summary.NoRequired
+		total.ServerOut += summary.ServerOut
+		total.NoTLS += summary.NoTLS
+		total.Removed += summary.Removed
+		total.Deduped += len(summary.Duplicates)
+		total.DedupedKeys += countKeyProxies(summary.KeyDups)
+		total.Bools += summary.Bools
+		total.Blocks += summary.Blocks
+		total.BlocksFixed += summary.BlocksFixed
+		for name, n := range summary.Transformed {
+			if total.Transformed == nil {
+				total.Transformed = map[string]int{}
+			}
+			total.Transformed[name] += n
+		}
+		if summary.Copied {
+			total.Copied++
+		}
+		if summary.NotConfig {
+			total.NotConfig++
+		}
+		if !ok {
+			total.Failed++
+			// Без -keep-going останавливаемся на первой ошибке
+			if !opts.KeepGoing {
+				total.NotReached = len(files) - i - 1
+				if total.NotReached > 0 {
+					sayf("⛔ Остановка на первой ошибке, файлов не обработано: %d (продолжить: -keep-going)\n", total.NotReached)
+				}
+				break
+			}
+		}
+	}
+
+	say()
+	switch {
+	case opts.Dir != "":
+		say("📊 ИТОГО ПО ПАПКЕ:")
+	case opts.Profiles != "":
+		say("📊 ИТОГО ПО ПРОФИЛЯМ:")
+	default:
+		say("📊 ИТОГО ПО ФАЙЛАМ:")
+	}
+	sayf("   📁 Файлов обработано: %d\n", len(total.Files)-total.Failed-total.NotConfig)
+	if state != nil {
+		sayf("   ⏭️  Пропущено без изменений (-state): %d\n", total.Unchanged)
+	}
+	if opts.MaxDepth >= 0 {
+		sayf("   ⏭️  Пропущено глубже -max-depth %d: %d\n", opts.MaxDepth, total.TooDeep)
+	}
+	if opts.RemoveIf != nil {
+		sayf("   🗑️  Удалено поле у прокси (%s=%s): %d\n", opts.RemoveIf.Key, opts.RemoveIf.Value, total.Removed)
+	} else {
+		sayf("   ✅ Добавлено skip-cert-verify: %d\n", total.Modified)
+		sayf("   ⚡ Уже имели skip-cert-verify: %d\n", total.AlreadyHas)
+	}
+	if opts.DenyServers != nil {
+		sayf("   🔒 Защищено списком -deny-servers: %d\n", total.Protected)
+	}
+	if opts.ErrorHosts != nil {
+		sayf("   ⏭️  Пропущено (нет в журнале ошибок): %d\n", total.Skipped)
+	}
+	if opts.nameFilter() {
+		sayf("   ⏭️  Пропущено фильтром по имени: %d\n", total.Filtered)
+	}
+	if opts.serverFilter() {
+		sayf("   ⏭️  Пропущено фильтром по серверу: %d\n", total.ServerOut)
+	}
+	if opts.OnlyTypeTLS {
+		sayf("   ⏭️  Пропущено без TLS (-only-type-tls): %d\n", total.NoTLS)
+	}
+	if len(opts.RequireFields) > 0 {
+		sayf("   ⏭️  Пропущено без поля -require-field (%s): %d\n", strings.Join(opts.RequireFields, ", "), total.NoRequired)
+	}
+	printTransformed(total.Transformed, opts, "   ")
+	if opts.DedupBy != "" {
+		sayf("   🗑️  Удалено повторяющихся прокси (-dedup-by %s): %d\n", opts.DedupBy, total.Deduped)
+	}
+	if opts.DedupKeys != "" {
+		sayf("   🧹 Исправлено прокси с повторяющимися полями (-dedup-keys %s): %d\n", opts.DedupKeys, total.DedupedKeys)
+	}
+	printNormalizedBools(opts, total.Bools, "   ")
+	printBlocks(opts, fixResult{Blocks: total.Blocks, BlocksFixed: total.BlocksFixed}, "   ")
+	if opts.Passthrough {
+		sayf("   📋 Скопировано без изменений: %d\n", total.Copied)
+	}
+	if opts.SkipNoProxies {
+		sayf("   ⏭️  Пропущено файлов без секции прокси (не конфиги): %d\n", total.NotConfig)
+	}
+	if total.Failed > 0 {
+		sayf("   ❌ Файлов с ошибками: %d\n", total.Failed)
+	}
+	if total.Unwritable > 0 {
+		sayf("   ❌ Пропущено: результат нельзя записать: %d\n", total.Unwritable)
+	}
+	if total.NotReached > 0 {
+		sayf("   ⛔ Не обработано после ошибки: %d\n", total.NotReached)
+	}
+
+	if state != nil && !opts.DryRun {
+		if err := state.save(opts.State); err != nil {
+			sayf("❌ Ошибка записи состояния -state: %v\n", err)
+			return 1
+		}
+	}
+
+	if opts.Report != "" {
+		if err := writeReport(opts.Report, report, opts.ReportChanged, opts.jsonIndent(), opts.now()); err != nil {
+			sayf("❌ Ошибка записи отчета: %v\n", err)
+			return 1
+		}
+		sayf("   📑 Отчет по прокси: %s\n", opts.Report)
+	}
+	if opts.EmitScript != "" {
+		if err := script.write(opts.EmitScript); err != nil {
+			sayf("❌ Ошибка записи скрипта -emit-script: %v\n", err)
+			return 1
+		}
+		sayf("   📜 Скрипт yq: %s, команд: %d\n", opts.EmitScript, script.edits)
+	}
+	if err := saveDiff(opts, diffs, "   "); err != nil {
+		sayf("❌ Ошибка записи -report-diff: %v\n", err)
+		return 1
+	}
+	if err := saveBundle(opts, bundle, "   "); err != nil {
+		sayf("❌ Ошибка записи -bundle: %v\n", err)
+		return 1
+	}
+	if opts.StatsFile != "" {
+		if err := appendStats(opts.StatsFile, total.Files); err != nil {
+			sayf("❌ Ошибка записи журнала -stats-file: %v\n", err)
+			return 1
+		}
+		sayf("   📈 Журнал статистики дополнен: %s\n", opts.StatsFile)
+	}
+
+	if total.Files == nil {
+		total.Files = []jsonSummary{}
+	}
+	webhookOK := true
+	if total.Failed == 0 && total.Unwritable == 0 {
+		webhookOK = summaryWebhook(opts, total, "   ")
+	}
+	if opts.JSON {
+		enc := jsonEncoder(os.Stdout, opts)
+		enc.Encode(total)
+	}
+	if total.Failed > 0 || total.Unwritable > 0 || !checkModifiedLimits(opts, total.Modified, total.Total) || !webhookOK {
+		return 1
+	}
+	return 0
+}
+
+// writableFiles проверяет до обработки, что результаты файлов можно
+// записать (checkWritable), и возвращает только такие файлы. Остальные
+// перечисляются сразу и не останавливают обработку, даже без -keep-going.
+// Папки внутри -out-dir и -backup-dir пакетный режим создает сам.
+func writableFiles(files []batchFile, total *batchSummary) []batchFile {
+	writable := files[:0]
+	for _, file := range files {
+		if err := checkWritable(file.Output, true); err != nil {
+			sayf("❌ %s: результат %s нельзя записать, файл пропущен: %v\n", file.Rel, file.Output, err)
+			total.Unwritable++
+			continue
+		}
+		if file.Backup != "" && !file.Copy {
+			if err := checkWritable(file.Backup, true); err != nil {
+				sayf("⚠️  %s: резервную копию %s не удастся записать: %v\n", file.Rel, file.Backup, err)
+			}
+		}
+		writable = append(writable, file)
+	}
+	return writable
+}
+
+// processBatchFile обрабатывает один файл пакетного режима и добавляет его
+// прокси в отчет и скрипт -emit-script. Возвращает итог и false, если файл
+// обработать не удалось.
+func processBatchFile(file batchFile, opts *options, report *proxyReport, script *editScript, diffs *diffArchive, bundle *bundleArchive) (jsonSummary, bool) {
+	summary := jsonSummary{Input: file.Input, Output: file.Output}
+
+	// Ссылкой может оказаться и файл из -in, и уже существующий результат
+	// или резервная копия
+	for _, path := range []string{file.Input, file.Output, file.Backup} {
+		if err := checkSymlink(path, opts); err != nil {
+			sayf("❌ %s: %v\n", file.Rel, err)
+			return summary, false
+		}
+	}
+
+	data, err := os.ReadFile(file.Input)
+	if err != nil {
+		sayf("❌ %s: ошибка чтения: %v\n", file.Rel, err)
+		return summary, false
+	}
+	if file.Copy {
+		return copyBatchFile(file, data, opts, summary)
+	}
+	original, enc, layers, err := readInput(data, opts)
+	if err != nil {
+		sayf("❌ %s: %v\n", file.Rel, err)
+		report.noteError(file.Rel, err)
+		return summary, false
+	}
+	report.noteInput(file.Rel, original, enc, layers, opts.EOL)
+	var backupFormat string
+	file.Backup, backupFormat = patchFallback(file.Backup, opts, enc, layers)
+	res, err := processFile(original, file.Input, opts)
+	if err != nil {
+		sayf("❌ %s: %v\n", file.Rel, err)
+		return summary, false
+	}
+
+	summary.Format = res.Format
+	summary.Modified = res.Modified
+	summary.AlreadyHas = res.AlreadyHas
+	summary.Total = res.total()
+	summary.Protected = res.Protected
+	summary.Skipped = res.Skipped
+	summary.Filtered = res.Filtered
+	summary.NoRequired = res.NoRequired
+	summary.ServerOut = res.ServerOut
+	summary.NoTLS = res.NoTLS
+	summary.Removed = res.Removed
+	summary.Duplicates = res.Duplicates
+	summary.KeyDups = res.KeyDups
+	summary.Bools = res.Bools
+	summary.Blocks = res.Blocks
+	summary.BlocksFixed = res.BlocksFixed
+	summary.Transformed = res.Transformed
+	summary.Stripped = res.Stripped
+	summary.Warnings = res.Warnings
+	summary.Changes = opts.redactChanges(res.Changes)
+	report.add(file.Rel, res.Proxies, opts)
+	script.add(file.Input, res.Proxies, opts)
+	diffs.add(file.Rel, file.Input, original, res.Content, opts)
+	if opts.Bundle != "" {
+		if err := bundle.add(file.Rel, file.Input, data, original, res.Content, enc, opts); err != nil {
+			sayf("❌ %s: -bundle: %v\n", file.Rel, err)
+			return summary, false
+		}
+	}
+
+	// Файл без прокси: с -passthrough копируется как есть, иначе пропускается
+	// Файл без секции прокси с -skip-no-proxies не считается конфигом
+	if opts.SkipNoProxies && !hasProxySection(original, opts.proxiesKeys()) {
+		summary.NotConfig = true
+		sayf("⏭️  %s: нет секции прокси, не конфиг — пропущен\n", file.Rel)
+		return summary, true
+	}
+	// Файл без прокси с -passthrough копируется как есть
+	if res.Format == "" && !res.EmptySection && opts.Passthrough {
+		return copyBatchFile(file, data, opts, summary)
+	}
+
+	if opts.RemoveIf != nil {
+		sayf("📄 %s: удалено %d\n", file.Rel, res.Removed)
+		printRemovals(res.Proxies, "")
+	} else {
+		sayf("📄 %s: добавлено %d, уже было %d\n", file.Rel, res.Modified, res.AlreadyHas)
+	}
+	printDuplicates(res.Duplicates, "   ")
+	printKeyDuplicates(res.KeyDups, opts, "   ")
+	printNoRequired(opts, res.Proxies, res.NoRequired, "   ")
+	printTLSReasons(opts, res.Proxies, "   ")
+	printBlocks(opts, res, "   ")
+	if !enc.isUTF8() {
+		sayf("   🔤 Кодировка: %s\n", enc.Name)
+	}
+	if len(layers) > 0 {
+		sayf("   🧩 Распаковано: %s\n", strings.Join(layers, " → "))
+	}
+	if backupFormat != opts.BackupFormat {
+		sayf("   ⚠️  Патч -backup-format patch для UTF-16 и -decode auto не строится, резервная копия — полная: %s\n", file.Backup)
+	}
+	if n := countNested(res.Proxies); n > 0 {
+		sayf("   🪆 Настроено во вложенном блоке (tls, reality-opts): %d\n", n)
+	}
+	if res.EmptySection {
+		sayf("   📭 Секции прокси нет, добавлен пустой список %s: []\n", opts.ProxiesKey)
+	}
+	if len(opts.KeepFields) > 0 {
+		sayf("   ✂️  Удалено полей: %d\n", res.Stripped)
+	}
+	printTransformed(res.Transformed, opts, "   ")
+	if opts.DenyServers != nil {
+		sayf("   🔒 Защищено списком -deny-servers: %d\n", res.Protected)
+	}
+	if opts.ValueMap != nil {
+		sayf("   🗺️  Значение из -map: %d\n", countMapValues(res.Proxies))
+	}
+	if n := countMarkerValues(res.Proxies); n > 0 {
+		sayf("   🎛️  Значение из комментария x509:value: %d\n", n)
+		printMarkerValues(res.Proxies)
+	}
+	if opts.ErrorHosts != nil {
+		summary.Matched = matchedProxies(res.Proxies, opts.ErrorHosts)
+		sayf("   🎯 Совпало с журналом ошибок: %s\n", strings.Join(summary.Matched, ", "))
+	}
+	for _, w := range res.Warnings {
+		sayf("   ⚠️  %s\n", w.text())
+	}
+	if opts.Explain {
+		printExplain(opts.redactChanges(res.Changes))
+	}
+	if opts.Strict && len(res.Warnings) > 0 {
+		sayf("   ❌ Режим -strict: результат не сохранен\n")
+		return summary, false
+	}
+
+	if opts.DryRun {
+		if opts.DiffOnlyChanged {
+			printChangedProxies(opts, res.Records)
+		} else if diff := renderDiff(opts, file.Input, file.Output, original, res.Content, res.Records); diff != "" {
+			sayf("%s", diff)
+		}
+		if err := writePreview(opts, file.Input, res.Content, enc); err != nil {
+			sayf("   ❌ Ошибка записи -dry-run-out: %v\n", err)
+			return summary, false
+		}
+		return summary, true
+	}
+
+	if !confirmOverwrite(opts, file.Output, file.Backup) {
+		return summary, true
+	}
+
+	previous, hasPrevious := readPrevious(file.Output)
+	if !opts.backupPrevious() {
+		backup := buildBackup(backupFormat, file.Input, file.Output, data, res.Content, enc)
+		if err := writeFileMkdir(file.Backup, backup, opts.FileMode); err != nil {
+			if opts.KeepOriginal {
+				keepOriginalError(file.Input, "", fmt.Errorf("Не удалось создать резервную копию: %w", err))
+				return summary, false
+			}
+			sayf("   ⚠️  Не удалось создать резервную копию: %v\n", err)
+		} else {
+			summary.Backup = file.Backup
+		}
+	}
+	err = saveOutput(opts, file.Output, file.Input, original, res.Content, enc, checkResult,
+		func(path string, data []byte) error { return writeFileMkdir(path, data, opts.FileMode) })
+	if err != nil {
+		sayf("   ❌ %v\n", err)
+		if opts.KeepOriginal {
+			keepOriginalError(file.Input, file.Backup, err)
+		}
+		return summary, false
+	}
+	summary.Written = true
+	sayf("   💾 %s\n", file.Output)
+	if opts.backupPrevious() && hasPrevious {
+		// Копия — прежний результат, только после успешной записи нового
+		if err := writeFileMkdir(file.Backup, previous, opts.FileMode); err != nil {
+			sayf("   ⚠️  Не удалось сохранить прежний результат в %s: %v\n", file.Backup, err)
+		} else {
+			summary.Backup = file.Backup
+		}
+	}
+	if !postHook(opts, file.Input, file.Output, res.Modified, &summary, "   ") {
+		return summary, false
+	}
+	return summary, true
+}
+
+// copyBatchFile копирует файл в папку результатов без изменений (-passthrough)
+func copyBatchFile(file batchFile, data []byte, opts *options, summary jsonSummary) (jsonSummary, bool) {
+	summary.Copied = true
+	if opts.DryRun {
+		sayf("📋 %s: будет скопирован без изменений\n", file.Rel)
+		return summary, true
+	}
+	if !confirmOverwrite(opts, file.Output) {
+		return summary, true
+	}
+	if err := writeFileMkdir(file.Output, data, opts.FileMode); err != nil {
+		sayf("❌ %s: ошибка копирования в %s: %v\n", file.Rel, file.Output, err)
+		return summary, false
+	}
+	summary.Written = true
+	sayf("📋 %s: скопирован без изменений\n", file.Rel)
+	return summary, true
+}
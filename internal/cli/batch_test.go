@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCollectBatchFilesDepth(t *testing.T) {
+	top := "top.yaml"
+	middle := filepath.Join("one", "middle.yaml")
+	deep := filepath.Join("one", "two", "deep.yaml")
+	cases := []struct {
+		args    []string
+		want    []string
+		tooDeep int
+	}{
+		{nil, []string{top}, 0},
+		{[]string{"-max-depth", "0"}, []string{top}, 2},
+		{[]string{"-max-depth", "1"}, []string{middle, top}, 1},
+		{[]string{"-max-depth", "2"}, []string{middle, deep, top}, 0},
+		{[]string{"-recursive"}, []string{middle, deep, top}, 0},
+	}
+	for _, c := range cases {
+		opts := testOptions(t, append([]string{"-dir", "testdata/depth"}, c.args...)...)
+		files, tooDeep, err := collectBatchFiles(opts)
+		if err != nil {
+			t.Fatalf("%v: %v", c.args, err)
+		}
+		var got []string
+		for _, file := range files {
+			got = append(got, file.Rel)
+		}
+		if !reflect.DeepEqual(got, c.want) || tooDeep != c.tooDeep {
+			t.Errorf("%v: файлы %q, глубже -max-depth %d; want %q, %d", c.args, got, tooDeep, c.want, c.tooDeep)
+		}
+	}
+}
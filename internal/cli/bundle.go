@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"archive/zip"
+	"bytes"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Раскладка архива -bundle: исходный и исправленный файл по одному
+// относительному пути в двух папках и общий diff, как у -report-diff
+const (
+	bundleOriginalDir = "original"
+	bundleFixedDir    = "fixed"
+	bundleDiffName    = "changes.diff"
+)
+
+// bundleArchive — архив для проверки изменений (-bundle)
+type bundleArchive struct {
+	files []bundleFile
+	diffs diffArchive
+}
+
+// bundleFile — входной файл и его результат
+type bundleFile struct {
+	Rel      string
+	Original []byte
+	Fixed    []byte
+}
+
+// add добавляет в архив входной файл path (raw — как прочитан с диска)
+// и его результат. Файлы без изменений тоже попадают в архив: так в нем
+// видно, что было обработано.
+func (b *bundleArchive) add(rel, path string, raw []byte, original, fixed string, enc textEncoding, opts *options) error {
+	out, err := encodeOutput(fixed, enc)
+	if err != nil {
+		return err
+	}
+	b.files = append(b.files, bundleFile{Rel: filepath.ToSlash(rel), Original: raw, Fixed: out})
+	b.diffs.add(rel, path, original, fixed, opts)
+	return nil
+}
+
+// write записывает архив в target атомарно (writeFileMkdir)
+func (b *bundleArchive) write(target string, opts *options, now time.Time) error {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	put := func(name string, data []byte) error {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	for _, f := range b.files {
+		if err := put(path.Join(bundleOriginalDir, f.Rel), f.Original); err != nil {
+			return err
+		}
+		if err := put(path.Join(bundleFixedDir, f.Rel), f.Fixed); err != nil {
+			return err
+		}
+	}
+	if err := put(bundleDiffName, []byte(b.diffs.text())); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return writeFileMkdir(target, buf.Bytes(), opts.FileMode)
+}
+
+// saveBundle записывает -bundle, если он задан, и выводит результат
+func saveBundle(opts *options, b *bundleArchive, indent string) error {
+	if opts.Bundle == "" {
+		return nil
+	}
+	if err := b.write(opts.Bundle, opts, opts.now()); err != nil {
+		return err
+	}
+	sayf("%s📦 Архив для проверки: %s (файлов: %d, %s)\n", indent, opts.Bundle, len(b.files), bundleDiffName)
+	return nil
+}
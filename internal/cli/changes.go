@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Действия над полями прокси
+const (
+	actionAdd    = "add"    // поле добавлено
+	actionMerge  = "merge"  // подключен якорь через <<
+	actionRemove = "remove" // поле удалено (-keep-fields)
+)
+
+// ProxyChange — одно изменение поля прокси. Список изменений собирается
+// только по запросу (-explain), чтобы обычная обработка оставалась быстрой.
+type ProxyChange struct {
+	Name     string `json:"name"`
+	Line     int    `json:"line"`
+	Field    string `json:"field"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+	Action   string `json:"action"`
+}
+
+// Options — параметры Fix. Пустые поля — значения флагов по умолчанию.
+type Options struct {
+	Field      string // добавляемое поле (-field), по умолчанию skip-cert-verify
+	Value      string // значение поля (-value), по умолчанию true
+	ProxiesKey string // ключ списка прокси (-proxies-key), по умолчанию proxies
+	Explain    bool   // собрать изменения по полям, как -explain
+}
+
+// Fix обрабатывает конфиг так же, как команда с флагами по умолчанию
+// и параметрами o, и возвращает новый текст. С o.Explain возвращает
+// и каждое изменение по полям — из этого списка строятся -explain
+// и отчеты. Без Explain список не собирается и равен nil. Ошибки в o
+// возвращаются как error; Fix можно вызывать из нескольких горутин.
+func Fix(content string, o Options) (string, []ProxyChange, error) {
+	opts := defaultOptions()
+	if field := strings.TrimSpace(o.Field); field != "" {
+		if err := checkPath(field); err != nil {
+			return content, nil, fmt.Errorf("Field: %w", err)
+		}
+		opts.Field = field
+	}
+	if value := strings.TrimSpace(o.Value); value != "" {
+		value, err := checkFieldValue(value, opts.FieldType)
+		if err != nil {
+			return content, nil, fmt.Errorf("Value: %w", err)
+		}
+		opts.Value = value
+	}
+	if key := strings.TrimSpace(o.ProxiesKey); key != "" {
+		opts.ProxiesKey = key
+	}
+	if err := checkFlavor(opts); err != nil {
+		return content, nil, err
+	}
+	opts.Explain = o.Explain
+	res, err := processDocument(content, opts)
+	if err != nil {
+		return content, nil, err
+	}
+	return res.Content, res.Changes, nil
+}
+
+// insertionChange описывает вставку keyValue ("ключ: значение") как изменение поля
+func insertionChange(name string, line int, keyValue string) ProxyChange {
+	key, _, _ := strings.Cut(keyValue, ":")
+	change := ProxyChange{
+		Name:     name,
+		Line:     line,
+		Field:    strings.TrimSpace(key),
+		NewValue: insertionValue(keyValue),
+		Action:   actionAdd,
+	}
+	if change.Field == "<<" {
+		change.Action = actionMerge
+	}
+	return change
+}
+
+// insertionValue возвращает значение вставки "ключ: значение"
+func insertionValue(keyValue string) string {
+	_, value, _ := strings.Cut(keyValue, ":")
+	return strings.TrimSpace(value)
+}
+
+// printExplain выводит изменения по полям (-explain), упорядоченные
+// по имени прокси
+func printExplain(changes []ProxyChange) {
+	sorted := make([]ProxyChange, len(changes))
+	copy(sorted, changes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	for _, c := range sorted {
+		switch c.Action {
+		case actionRemove:
+			sayf("• %s (строка %d): - %s: %s\n", c.Name, c.Line, c.Field, c.OldValue)
+		default:
+			sayf("• %s (строка %d): + %s: %s (%s)\n", c.Name, c.Line, c.Field, c.NewValue, c.Action)
+		}
+	}
+}
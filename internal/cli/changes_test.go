@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestFix(t *testing.T) {
+	content := `all-proxies:
+  - { name: A, type: trojan, server: a.example.com, port: 443 }
+  - name: B
+    type: vmess
+    server: b.example.com
+    port: 443
+    allow-insecure: 0
+  - name: C
+    type: vless
+    server: c.example.com
+    port: 443
+`
+	want := `all-proxies:
+  - { name: A, type: trojan, server: a.example.com, port: 443, allow-insecure: 1 }
+  - name: B
+    type: vmess
+    server: b.example.com
+    port: 443
+    allow-insecure: 0
+  - name: C
+    allow-insecure: 1
+    type: vless
+    server: c.example.com
+    port: 443
+`
+	o := Options{Field: "allow-insecure", Value: "1", ProxiesKey: "all-proxies"}
+	got, changes, err := Fix(content, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Fix:\n%s\nwant\n%s", got, want)
+	}
+	if changes != nil {
+		t.Errorf("без Explain изменения собраны: %+v", changes)
+	}
+
+	o.Explain = true
+	got, changes, err = Fix(content, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Fix с Explain:\n%s\nwant\n%s", got, want)
+	}
+	wantChanges := []ProxyChange{
+		{Name: "A", Line: 2, Field: "allow-insecure", NewValue: "1", Action: actionAdd},
+		{Name: "C", Line: 8, Field: "allow-insecure", NewValue: "1", Action: actionAdd},
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("изменения = %+v, want %+v", changes, wantChanges)
+	}
+
+	if _, _, err := Fix(content, Options{Field: "tls..insecure"}); err == nil {
+		t.Error("Fix с неверным Field: нет ошибки")
+	}
+}
+
+// TestFixConcurrent вызывает Fix из нескольких горутин сразу: с go test
+// -race проверяет, что общие кэши выражений защищены
+func TestFixConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Свое поле у каждой горутины: выражения компилируются и пишутся в кэш одновременно
+			field := fmt.Sprintf("x-field-%d", i%4)
+			content := fmt.Sprintf("proxies:\n  - { name: P%d, type: trojan, server: p.example.com, port: 443 }\n", i)
+			want := fmt.Sprintf("proxies:\n  - { name: P%d, type: trojan, server: p.example.com, port: 443, %s: true }\n", i, field)
+			got, changes, err := Fix(content, Options{Field: field, Explain: true})
+			switch {
+			case err != nil:
+				errs <- err
+			case got != want || len(changes) != 1:
+				errs <- fmt.Errorf("Fix = %q, %d изменений, want %q", got, len(changes), want)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
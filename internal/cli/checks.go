@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+)
+
+// checkModifiedLimits проверяет пороги -min-modified и -max-modified: при
+// выходе за них сообщает порог и фактическое число в stderr и возвращает false.
+// Так CI замечает случаи, когда формат конфига изменился и прокси перестали
+// находиться, или, наоборот, изменено намного больше ожидаемого.
+// found — число найденных прокси: с -no-op-exit-zero запуск, в котором
+// прокси не найдены, всегда успешен.
+func checkModifiedLimits(opts *options, modified, found int) bool {
+	if found == 0 && opts.NoOpExitZero {
+		return true
+	}
+	if modified < opts.MinModified {
+		fmt.Fprintf(errConsole, "❌ Изменено прокси: %d, требуется не меньше %d (-min-modified)\n",
+			modified, opts.MinModified)
+		return false
+	}
+	if opts.MaxModified >= 0 && modified > opts.MaxModified {
+		fmt.Fprintf(errConsole, "❌ Изменено прокси: %d, допускается не больше %d (-max-modified)\n",
+			modified, opts.MaxModified)
+		return false
+	}
+	return true
+}
+
+// checkResult проверяет результат перед записью поверх исходного файла
+// (-keep-original-on-error): если исходный YAML разбирается, а результат
+// нет, результат не записывается. Для markdown проверяется блок YAML,
+// с -markdown — каждый блок.
+func checkResult(original, result, path string, opts *options) error {
+	if opts.Markdown && isMarkdownInput(path, opts) {
+		before, after := markdownBlocks(original), markdownBlocks(result)
+		if len(before) != len(after) {
+			return nil
+		}
+		for i := range before {
+			if err := checkBlock(original[before[i].start:before[i].end], result[after[i].start:after[i].end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if isMarkdownInput(path, opts) && opts.OutputFormat != "yaml" {
+		before, err1 := splitMarkdown(original)
+		after, err2 := splitMarkdown(result)
+		if err1 != nil || err2 != nil {
+			return nil
+		}
+		original, result = before.block, after.block
+	}
+	return checkBlock(original, result)
+}
+
+// checkBlock возвращает ошибку, если исходный YAML разбирается, а результат нет
+func checkBlock(original, result string) error {
+	if _, err := loadDocument(original); err != nil {
+		return nil
+	}
+	if _, err := loadDocument(result); err != nil {
+		return fmt.Errorf("Результат не разбирается как YAML: %w", err)
+	}
+	return nil
+}
+
+// saveOutput записывает результат content в файл output в кодировке enc
+// через write. С -keep-original-on-error результат сначала проверяет check
+// (checkResult): если проверка не прошла, запись не начинается. Запись
+// атомарна, поэтому при любой ошибке output остается прежним — с -in-place
+// это и есть исходный файл input.
+func saveOutput(opts *options, output, input, original, content string, enc textEncoding,
+	check func(original, result, path string, opts *options) error, write func(path string, data []byte) error) error {
+	if opts.KeepOriginal {
+		if err := check(original, content, input, opts); err != nil {
+			return err
+		}
+	}
+	out, err := encodeOutput(content, enc)
+	if err != nil {
+		return fmt.Errorf("Ошибка перекодирования в %s: %w", enc.Name, err)
+	}
+	if err := write(output, out); err != nil {
+		return fmt.Errorf("Ошибка сохранения %s: %w", output, err)
+	}
+	return nil
+}
+
+// keepOriginalError сообщает об ошибке после создания резервной копии
+// (-keep-original-on-error): исходный файл не изменялся, копия указана для
+// восстановления вручную
+func keepOriginalError(input, backup string, err error) {
+	fmt.Fprintf(errConsole, "❌ %v\n", err)
+	if backup != "" {
+		fmt.Fprintf(errConsole, "🛟 Исходный файл %s не изменен, резервная копия: %s\n", input, backup)
+	} else {
+		fmt.Fprintf(errConsole, "🛟 Исходный файл %s не изменен\n", input)
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSaveOutputKeepsOriginal проверяет -in-place с -keep-original-on-error:
+// если проверка результата или запись не прошли, исходный файл остается
+// байт в байт, и временных файлов рядом с ним нет
+func TestSaveOutputKeepsOriginal(t *testing.T) {
+	input, err := os.ReadFile("testdata/mixed_entries.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := testOptions(t, "-in-place", "-keep-original-on-error")
+	original, enc, _, err := readInput(input, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := processContent(original, opts)
+	if err != nil || res.Modified == 0 {
+		t.Fatalf("processContent: добавлено %d, %v", res.Modified, err)
+	}
+	write := func(path string, data []byte) error { return writeFile(path, data, opts.FileMode) }
+	errCheck := errors.New("проверка не прошла")
+	errWrite := errors.New("диск заполнен")
+
+	cases := []struct {
+		name    string
+		content string
+		check   func(original, result, path string, opts *options) error
+		write   func(path string, data []byte) error
+		want    error
+	}{
+		{"проверка", res.Content, func(string, string, string, *options) error { return errCheck }, write, errCheck},
+		{"не YAML", res.Content + "proxies: [\n", checkResult, write, nil},
+		{"запись", res.Content, checkResult, func(string, []byte) error { return errWrite }, errWrite},
+	}
+	for _, c := range cases {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "config.yaml")
+		if err := os.WriteFile(path, input, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		err := saveOutput(opts, path, path, original, c.content, enc, c.check, c.write)
+		if err == nil || c.want != nil && !errors.Is(err, c.want) {
+			t.Errorf("%s: ошибка %v, want %v", c.name, err, c.want)
+		}
+		after, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(after) != string(input) {
+			t.Errorf("%s: исходный файл изменился:\n%s", c.name, after)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+			t.Errorf("%s: в папке %d файлов, want 1", c.name, len(entries))
+		}
+	}
+
+	// Без ошибок результат записывается поверх исходного файла
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, input, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveOutput(opts, path, path, original, res.Content, enc, checkResult, write); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile(path); string(after) != res.Content {
+		t.Errorf("результат не записан:\n%s", after)
+	}
+}
@@ -0,0 +1,230 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Значения поля в сравнении (-compare)
+const (
+	compareNoField = "none" // у прокси нет поля
+)
+
+// Результаты сравнения прокси
+const (
+	compareDiffers   = "differs"       // значения поля расходятся
+	compareOnlyLeft  = "only-in-left"  // прокси есть только в первом конфиге
+	compareOnlyRight = "only-in-right" // прокси есть только во втором конфиге
+)
+
+// compareEntry — прокси, который отличается в двух конфигах
+type compareEntry struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Left   string `json:"left,omitempty"`  // значение в первом конфиге
+	Right  string `json:"right,omitempty"` // значение во втором конфиге
+}
+
+// compareResult — итог -compare в режиме -json
+type compareResult struct {
+	Left        string         `json:"left"`
+	Right       string         `json:"right"`
+	By          string         `json:"by"`
+	Same        int            `json:"same"`
+	Differences []compareEntry `json:"differences"`
+}
+
+// compareProxy — прокси из конфига для сравнения
+type compareProxy struct {
+	key   string
+	value string
+}
+
+// loadConfigText читает конфиг и возвращает его YAML (для markdown — блок
+// ```yaml) и число строк перед ним
+func loadConfigText(path string, opts *options) (string, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	content, _, _, err := readInput(data, opts)
+	if err != nil {
+		return "", 0, err
+	}
+	if !isMarkdownInput(path, opts) {
+		return content, 0, nil
+	}
+	md, err := splitMarkdown(content)
+	if err != nil {
+		return "", 0, err
+	}
+	return md.block, strings.Count(md.before, "\n"), nil
+}
+
+// loadProxyList читает конфиг и возвращает список прокси (nil, если
+// секции нет) и число строк перед YAML (для markdown — до блока ```yaml)
+func loadProxyList(path string, opts *options) (*yaml.Node, int, error) {
+	content, offset, err := loadConfigText(path, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	doc, err := loadDocument(content)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка разбора YAML: %w", err)
+	}
+	return proxiesNode(doc, opts.proxiesKeys()), offset, nil
+}
+
+// loadProxyFields читает конфиг через ParseProxies и возвращает поля
+// каждого прокси в порядке следования. Ключи слияния << раскрыты, так что
+// значения из якорей тоже учитываются.
+func loadProxyFields(path string, opts *options) ([]map[string]interface{}, error) {
+	content, _, err := loadConfigText(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := ParseProxies(content, opts.proxiesKeys()...)
+	if err != nil {
+		return nil, err
+	}
+	proxies := make([]map[string]interface{}, 0, len(parsed))
+	for _, p := range parsed {
+		proxies = append(proxies, p.Fields())
+	}
+	return proxies, nil
+}
+
+// loadCompareProxies читает конфиг и возвращает прокси в порядке следования
+// со значением поля (с учетом -placement и якорей) и ключом сопоставления
+func loadCompareProxies(path string, opts *options) ([]compareProxy, error) {
+	all, err := loadProxyFields(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	var proxies []compareProxy
+	seen := map[string]bool{}
+	for _, fields := range all {
+		key := fmt.Sprint(fields["name"])
+		if opts.CompareBy == "server" {
+			key = strings.ToLower(fmt.Sprint(fields["server"])) + ":" + fmt.Sprint(fields["port"])
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		proxies = append(proxies, compareProxy{
+			key:   key,
+			value: pathValue(fields, opts.placement(fmt.Sprint(fields["type"]))),
+		})
+	}
+	return proxies, nil
+}
+
+// pathValue возвращает значение по пути path или compareNoField
+func pathValue(fields map[string]interface{}, path string) string {
+	var value interface{} = fields
+	for _, key := range strings.Split(path, ".") {
+		mapping, ok := value.(map[string]interface{})
+		if !ok {
+			return compareNoField
+		}
+		if value, ok = mapping[key]; !ok {
+			return compareNoField
+		}
+	}
+	text := fmt.Sprint(value)
+	if b, ok := parseBool(text); ok {
+		return b
+	}
+	return text
+}
+
+// compareConfigs сопоставляет прокси двух конфигов и возвращает отличия
+func compareConfigs(left, right []compareProxy) (same int, diffs []compareEntry) {
+	rightValues := map[string]string{}
+	for _, p := range right {
+		rightValues[p.key] = p.value
+	}
+	leftKeys := map[string]bool{}
+	for _, p := range left {
+		leftKeys[p.key] = true
+		value, ok := rightValues[p.key]
+		switch {
+		case !ok:
+			diffs = append(diffs, compareEntry{Key: p.key, Status: compareOnlyLeft, Left: p.value})
+		case value != p.value:
+			diffs = append(diffs, compareEntry{Key: p.key, Status: compareDiffers, Left: p.value, Right: value})
+		default:
+			same++
+		}
+	}
+	for _, p := range right {
+		if !leftKeys[p.key] {
+			diffs = append(diffs, compareEntry{Key: p.key, Status: compareOnlyRight, Right: p.value})
+		}
+	}
+	return same, diffs
+}
+
+// runCompare сравнивает входной конфиг с -compare и возвращает код выхода:
+// 0 — отличий нет, 1 — есть отличия, 2 — ошибка чтения
+func runCompare(leftFile string, opts *options) int {
+	left, err := loadCompareProxies(leftFile, opts)
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ %s: %v\n", leftFile, err)
+		return 2
+	}
+	right, err := loadCompareProxies(opts.Compare, opts)
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ %s: %v\n", opts.Compare, err)
+		return 2
+	}
+	same, diffs := compareConfigs(left, right)
+
+	if opts.JSON {
+		result := compareResult{Left: leftFile, Right: opts.Compare, By: opts.CompareBy, Same: same, Differences: diffs}
+		if result.Differences == nil {
+			result.Differences = []compareEntry{}
+		}
+		enc := jsonEncoder(os.Stdout, opts)
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(errConsole, "❌ Ошибка вывода JSON: %v\n", err)
+			return 2
+		}
+	} else {
+		sayf("🔀 СРАВНЕНИЕ: %s ↔ %s\n", leftFile, opts.Compare)
+		say("══════════════════════════════════════════════")
+		if len(diffs) == 0 {
+			say("Отличий нет")
+		} else {
+			tw := tabwriter.NewWriter(console, 0, 0, 2, ' ', 0)
+			fmt.Fprintf(tw, "Прокси\t%s\t%s\n", leftFile, opts.Compare)
+			for _, d := range diffs {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Key, compareCell(d.Left), compareCell(d.Right))
+			}
+			tw.Flush()
+		}
+		say("══════════════════════════════════════════════")
+		sayf("   🟰 Совпадает: %d\n", same)
+		sayf("   ≠  Отличается: %d\n", len(diffs))
+	}
+	if len(diffs) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// compareCell возвращает значение для таблицы сравнения
+func compareCell(value string) string {
+	switch value {
+	case "":
+		return "— нет прокси"
+	case compareNoField:
+		return "нет поля"
+	}
+	return value
+}
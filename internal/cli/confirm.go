@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// isInteractive проверяет, что stdin — терминал и можно задать вопрос
+func isInteractive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// pause ждет Enter перед выходом, чтобы окно консоли, открытое двойным
+// щелчком, не закрылось сразу. С -quiet и -yes не ждет.
+func pause(opts *options) {
+	if !opts.Quiet && !opts.Yes {
+		fmt.Scanln()
+	}
+}
+
+// confirmOverwrite спрашивает разрешение перезаписать уже существующие файлы
+// (с -confirm-overwrite). Без терминала, в режимах -quiet и -json ответ — «нет»;
+// -force и -yes разрешают перезапись без вопроса.
+func confirmOverwrite(opts *options, paths ...string) bool {
+	if !opts.ConfirmOverwrite || opts.Force || opts.Yes {
+		return true
+	}
+	var existing []string
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	if len(existing) == 0 {
+		return true
+	}
+
+	list := strings.Join(existing, ", ")
+	if opts.Quiet || opts.JSON || !isInteractive() {
+		fmt.Fprintf(errConsole, "⏭️  Файлы уже существуют: %s — не перезаписаны (используйте -force)\n", list)
+		return false
+	}
+	fmt.Fprintf(console, "❓ Файлы уже существуют: %s. Перезаписать? [y/N] ", list)
+	var answer string
+	fmt.Scanln(&answer)
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "д", "да":
+		return true
+	}
+	say("⏭️  Перезапись отменена")
+	return false
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/text/message"
+)
+
+// console — вывод сообщений для пользователя. В режиме -json сообщения
+// отключаются, чтобы в stdout попадал только JSON.
+var console io.Writer = os.Stdout
+
+// say выводит строку сообщения
+func say(a ...interface{}) {
+	fmt.Fprintln(console, a...)
+}
+
+// printer форматирует числа по правилам языка -locale (например, 12,345
+// или 12 345); nil — числа выводятся как есть
+var printer *message.Printer
+
+// sayf выводит форматированное сообщение
+func sayf(format string, a ...interface{}) {
+	if printer != nil {
+		printer.Fprintf(console, format, a...)
+		return
+	}
+	fmt.Fprintf(console, format, a...)
+}
+
+// errConsole — вывод ошибок (stderr), в том числе через log
+var errConsole io.Writer = os.Stderr
+
+// emojiMarkers — замена эмодзи в начале сообщений для -emoji=false:
+// значимые получают текстовую метку, декоративные убираются
+var emojiMarkers = map[string]string{
+	"✅": "[OK]",
+	"⚠": "[WARN]",
+	"❌": "[ERR]",
+	"⛔": "[ERR]",
+	"⏭": "[SKIP]",
+	"❓": "[?]",
+	"📊": "", "📋": "", "📄": "", "📁": "", "📂": "", "📑": "", "📖": "", "📝": "",
+	"📭": "", "💾": "", "🔍": "", "🔒": "", "🔗": "", "🔤": "", "🔀": "", "🟰": "",
+	"⚡": "", "✂": "", "🗺": "", "🎛": "", "🎯": "", "🧩": "", "🪆": "", "🧹": "",
+	"🧾": "", "📈": "", "🛡": "", "🚀": "", "🔧": "", "🛟": "", "📜": "", "🗑": "", "🔎": "", "👀": "", "🪝": "", "🧭": "", "💡": "", "📦": "", "🔐": "", "🧱": "", "≠": "",
+}
+
+// boxReplacer заменяет символы рамки баннера и разделителей на ASCII
+var boxReplacer = strings.NewReplacer("═", "=", "║", "|", "╔", "+", "╗", "+", "╚", "+", "╝", "+")
+
+// plainLine убирает эмодзи в начале строки сообщения. Заменяется только
+// префикс: имена прокси с эмодзи (например, в diff) выводятся как есть.
+func plainLine(line string) string {
+	body := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(body)]
+	switch {
+	case strings.HasPrefix(body, "•"):
+		return indent + "*" + strings.TrimPrefix(body, "•")
+	case strings.ContainsAny(firstRune(body), "═║╔╚"):
+		return indent + boxReplacer.Replace(body)
+	}
+	for emoji, marker := range emojiMarkers {
+		if !strings.HasPrefix(body, emoji) {
+			continue
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(body[len(emoji):], "\uFE0F"), " ")
+		if marker == "" {
+			return indent + rest
+		}
+		return indent + marker + " " + rest
+	}
+	return line
+}
+
+// firstRune возвращает первый символ строки
+func firstRune(s string) string {
+	for _, r := range s {
+		return string(r)
+	}
+	return ""
+}
+
+// plainWriter выводит текст без эмодзи (-emoji=false, NO_COLOR).
+// Каждый вызов Write считается начинающимся с новой строки: сообщения
+// выводятся целыми строками.
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	lines := strings.Split(string(b), "\n")
+	for i, line := range lines {
+		lines[i] = plainLine(line)
+	}
+	if _, err := io.WriteString(p.w, strings.Join(lines, "\n")); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// disableEmoji переключает весь вывод (stdout, stderr и log) на ASCII-метки
+func disableEmoji() {
+	console = plainWriter{console}
+	errConsole = plainWriter{errConsole}
+	log.SetOutput(errConsole)
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEmojiDisabled проверяет, что с -emoji=false в выводе пакетной
+// обработки нет эмодзи: ни символов от U+1F000, ни символов U+2600–27BF
+// (✅, ⚠, ❌, ⚡ и другие)
+func TestEmojiDisabled(t *testing.T) {
+	var out bytes.Buffer
+	savedConsole, savedErr := console, errConsole
+	t.Cleanup(func() {
+		console, errConsole = savedConsole, savedErr
+		log.SetOutput(os.Stderr)
+	})
+	console, errConsole = &out, &out
+
+	// Файлы без эмодзи в самих конфигах: имена прокси выводятся как есть
+	args := []string{"-yes", "-dry-run", "-explain", "-emoji=false"}
+	for _, name := range []string{"mixed_entries.yaml", "duplicate_names.yaml", "duplicate_keys.yaml",
+		"tls_types.yaml", "typo_fields.yaml", "unknown_type.yaml", "truncated.yaml", "sni_mismatch.yaml"} {
+		args = append(args, filepath.Join("testdata", name))
+	}
+	opts := testOptions(t, args...)
+	runBatch(opts)
+	printStarterConfig()
+
+	if out.Len() == 0 {
+		t.Fatal("нет вывода")
+	}
+	for i, line := range strings.Split(out.String(), "\n") {
+		for _, r := range line {
+			if r >= 0x1F000 || (r >= 0x2600 && r <= 0x27BF) {
+				t.Errorf("строка %d: символ %q (U+%04X): %s", i+1, r, r, line)
+				break
+			}
+		}
+	}
+}
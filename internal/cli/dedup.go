@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Способы -dedup-by
+const dedupByName = "name" // прокси с тем же name, что у одного из предыдущих
+
+// duplicate — прокси, удаленный как повтор (-dedup-by)
+type duplicate struct {
+	Name    string `json:"name"`
+	Type    string `json:"type,omitempty"`
+	Server  string `json:"server,omitempty"`
+	Line    int    `json:"line"`
+	First   int    `json:"first_line"`        // строка прокси, который оставлен
+	Differs bool   `json:"differs,omitempty"` // поля отличаются от оставленного
+}
+
+// dedupProxies удаляет прокси, имя которых уже встречалось выше
+// (-dedup-by name): остается первый. Записи вырезаются из текста вместе
+// со строками, остальное форматирование не меняется; список в потоковом
+// стиле записывается заново, как в структурном способе. Конфиг, который
+// не разбирается, возвращается как есть: об этом предупредит checkConfig.
+func dedupProxies(content string, opts *options) (string, []duplicate, error) {
+	doc, err := loadDocument(content)
+	if err != nil {
+		return content, nil, nil
+	}
+	seq := opts.proxyList(doc)
+	if seq == nil {
+		return content, nil, nil
+	}
+
+	var dups []duplicate
+	drop := map[*yaml.Node]bool{}
+	first := map[string]*yaml.Node{}
+	for _, item := range seq.Content {
+		name := scalarValue(item, "name")
+		if name == "" {
+			continue
+		}
+		original, seen := first[name]
+		if !seen {
+			first[name] = item
+			continue
+		}
+		dups = append(dups, duplicate{
+			Name:    name,
+			Type:    scalarValue(item, "type"),
+			Server:  scalarValue(item, "server"),
+			Line:    item.Line,
+			First:   original.Line,
+			Differs: !sameProxy(original, item),
+		})
+		drop[item] = true
+	}
+	if len(dups) == 0 {
+		return content, nil, nil
+	}
+
+	if out, ok := cutEntries(content, dups, opts); ok {
+		return out, dups, nil
+	}
+	kept := seq.Content[:0]
+	for _, item := range seq.Content {
+		if !drop[item] {
+			kept = append(kept, item)
+		}
+	}
+	seq.Content = kept
+	out, err := encodeDocument(doc, content)
+	if err != nil {
+		return content, nil, fmt.Errorf("-dedup-by: ошибка записи YAML: %w", err)
+	}
+	if flowSection(content, opts.proxiesKeys()) {
+		out = spliceFlowLine(content, out, opts.proxiesKeys())
+	}
+	return out, dups, nil
+}
+
+// sameProxy сравнивает поля двух прокси после раскрытия ключей слияния
+func sameProxy(a, b *yaml.Node) bool {
+	pa, errA := decodeProxy(a)
+	pb, errB := decodeProxy(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return reflect.DeepEqual(pa.Fields(), pb.Fields())
+}
+
+// cutEntries вырезает из текста записи повторов целыми строками вместе
+// с комментариями прямо над ними. Если
+// запись не найдена в тексте или делит строку с другими (список в потоковом
+// стиле), возвращает false.
+func cutEntries(content string, dups []duplicate, opts *options) (string, bool) {
+	if flowDocument(content) || flowSection(content, opts.proxiesKeys()) || aliasedSection(content, opts.proxiesKeys()) {
+		return content, false
+	}
+	entries, _ := proxyEntries(content, opts.proxiesKeys())
+	byLine := map[int]proxyEntry{}
+	for _, entry := range entries {
+		byLine[entry.Line] = entry
+	}
+
+	type span struct{ start, end int }
+	spans := make([]span, 0, len(dups))
+	for _, dup := range dups {
+		entry, ok := byLine[dup.Line]
+		if !ok {
+			return content, false
+		}
+		lineStart := strings.LastIndexByte(content[:entry.Start], '\n') + 1
+		if strings.TrimSpace(content[lineStart:entry.Start]) != "" {
+			return content, false
+		}
+		lineStart = commentsAbove(content, lineStart)
+		end := len(content)
+		if i := strings.IndexByte(content[entry.End:], '\n'); i >= 0 {
+			end = entry.End + i + 1
+		}
+		spans = append(spans, span{lineStart, end})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+	for _, s := range spans {
+		content = content[:s.start] + content[s.end:]
+	}
+	return content, true
+}
+
+// commentsAbove возвращает начало строк-комментариев, которые идут
+// без пустых строк прямо над строкой, начинающейся с pos
+func commentsAbove(content string, pos int) int {
+	for pos > 0 {
+		prev := strings.LastIndexByte(content[:pos-1], '\n') + 1
+		if !strings.HasPrefix(strings.TrimSpace(content[prev:pos]), "#") {
+			break
+		}
+		pos = prev
+	}
+	return pos
+}
+
+// dedupWarnings заменяет предупреждения duplicate-name из checkConfig:
+// повторы уже удалены, а предупреждение остается только у тех, чьи поля
+// отличаются от оставленного прокси — скорее всего, это ошибка в конфиге
+func dedupWarnings(warnings []warning, dups []duplicate) []warning {
+	out := warnings[:0]
+	for _, w := range warnings {
+		if w.Kind != warnDuplicateName {
+			out = append(out, w)
+		}
+	}
+	for _, dup := range dups {
+		if dup.Differs {
+			out = append(out, warning{
+				Kind:    warnDuplicateName,
+				Proxy:   dup.Name,
+				Line:    dup.Line,
+				Value:   dup.Name,
+				Message: fmt.Sprintf("повторяющееся имя прокси с другими полями: оставлен прокси со строки %d, этот удален", dup.First),
+			})
+		}
+	}
+	return out
+}
+
+// printDuplicates выводит прокси, удаленные как повторы (-dedup-by)
+func printDuplicates(dups []duplicate, indent string) {
+	if len(dups) == 0 {
+		return
+	}
+	sayf("%s🗑️  Удалено повторяющихся прокси (-dedup-by name): %d\n", indent, len(dups))
+	for _, dup := range dups {
+		note := ""
+		if dup.Differs {
+			note = ", поля отличаются"
+		}
+		sayf("%s   • %s (строка %d, оставлен со строки %d%s)\n", indent, dup.Name, dup.Line, dup.First, note)
+	}
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Какое из повторяющихся полей оставляет -dedup-keys
+const (
+	dedupKeysLast  = "last"  // последнее: так читает конфиг большинство клиентов
+	dedupKeysFirst = "first" // первое
+)
+
+// keyDuplicate — прокси, в записи которого поле задано несколько раз
+type keyDuplicate struct {
+	Name    string `json:"name"`
+	Line    int    `json:"line"`
+	Key     string `json:"key"`
+	Lines   []int  `json:"lines"`             // строки всех вхождений поля
+	Differs bool   `json:"differs,omitempty"` // значения вхождений отличаются
+}
+
+// findKeyDuplicates находит в записях прокси поля, заданные несколько раз
+// (например, skip-cert-verify, дописанный дважды). Сравниваются ключи
+// верхнего уровня записи; с -ignore-case-keys — без учета регистра,
+// дефисов и подчеркиваний. Для каждого повтора возвращаются пары
+// ключ-значение по порядку вхождения.
+func findKeyDuplicates(seq *yaml.Node, opts *options) ([]keyDuplicate, [][][2]*yaml.Node) {
+	var dups []keyDuplicate
+	var pairs [][][2]*yaml.Node
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		seen := map[int]bool{}
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			if seen[i] {
+				continue
+			}
+			key := item.Content[i]
+			group := [][2]*yaml.Node{{key, item.Content[i+1]}}
+			for j := i + 2; j+1 < len(item.Content); j += 2 {
+				if sameKey(key.Value, item.Content[j].Value, opts.IgnoreCaseKeys) {
+					seen[j] = true
+					group = append(group, [2]*yaml.Node{item.Content[j], item.Content[j+1]})
+				}
+			}
+			if len(group) == 1 || key.Value == "<<" {
+				continue
+			}
+			dup := keyDuplicate{Name: scalarValue(item, "name"), Line: item.Line, Key: key.Value}
+			for _, pair := range group {
+				dup.Lines = append(dup.Lines, pair[0].Line)
+				if !sameNode(pair[1], group[0][1]) {
+					dup.Differs = true
+				}
+			}
+			dups = append(dups, dup)
+			pairs = append(pairs, group)
+		}
+	}
+	return dups, pairs
+}
+
+// sameNode сравнивает значения двух полей как YAML
+func sameNode(a, b *yaml.Node) bool {
+	var va, vb interface{}
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	return fmt.Sprint(va) == fmt.Sprint(vb)
+}
+
+// keyWarnings — предупреждения duplicate-key для checkConfig
+func keyWarnings(seq *yaml.Node, opts *options) []warning {
+	dups, _ := findKeyDuplicates(seq, opts)
+	warnings := make([]warning, 0, len(dups))
+	for _, dup := range dups {
+		msg := fmt.Sprintf("поле %s задано в записи прокси %d раза (строки %s)", dup.Key, len(dup.Lines), joinLines(dup.Lines))
+		if dup.Differs {
+			msg += " с разными значениями"
+		}
+		warnings = append(warnings, warning{
+			Kind:    warnDuplicateKey,
+			Proxy:   dup.Name,
+			Line:    dup.Lines[1],
+			Value:   dup.Key,
+			Message: msg,
+		})
+	}
+	return warnings
+}
+
+// joinLines перечисляет номера строк через запятую
+func joinLines(lines []int) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		parts[i] = fmt.Sprint(line)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// dedupKeys оставляет в записях прокси одно вхождение каждого поля
+// (-dedup-keys last или first). Лишние вхождения вырезаются из текста,
+// остальное форматирование не меняется; если поле делит строку с другими
+// и вырезать его нельзя, конфиг записывается заново, как в структурном способе.
+func dedupKeys(content string, opts *options) (string, []keyDuplicate, error) {
+	doc, err := loadDocument(content)
+	if err != nil {
+		return content, nil, nil
+	}
+	seq := opts.proxyList(doc)
+	if seq == nil {
+		return content, nil, nil
+	}
+	dups, groups := findKeyDuplicates(seq, opts)
+	if len(dups) == 0 {
+		return content, nil, nil
+	}
+
+	drop := map[*yaml.Node]bool{}
+	for _, group := range groups {
+		keep := len(group) - 1
+		if opts.DedupKeys == dedupKeysFirst {
+			keep = 0
+		}
+		for i, pair := range group {
+			if i != keep {
+				drop[pair[0]] = true
+			}
+		}
+	}
+
+	if out, ok := cutKeys(content, groups, drop); ok {
+		return out, dups, nil
+	}
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		kept := item.Content[:0]
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			if !drop[item.Content[i]] {
+				kept = append(kept, item.Content[i], item.Content[i+1])
+			}
+		}
+		item.Content = kept
+	}
+	out, err := encodeDocument(doc, content)
+	if err != nil {
+		return content, nil, fmt.Errorf("-dedup-keys: ошибка записи YAML: %w", err)
+	}
+	if flowSection(content, opts.proxiesKeys()) {
+		out = spliceFlowLine(content, out, opts.proxiesKeys())
+	}
+	return out, dups, nil
+}
+
+// cutKeys вырезает из текста лишние вхождения полей: в многострочной
+// записи — строку целиком, в компактной — "ключ: значение" с запятой.
+// Значение должно быть в той же строке, что и ключ; иначе возвращает false.
+func cutKeys(content string, groups [][][2]*yaml.Node, drop map[*yaml.Node]bool) (string, bool) {
+	if flowDocument(content) || isJSONDocument(content) {
+		return content, false
+	}
+	lineStarts := lineOffsets(content)
+	offset := func(n *yaml.Node) int { return nodeOffset(content, lineStarts, n) }
+
+	type span struct{ start, end int }
+	var spans []span
+	for _, group := range groups {
+		for _, pair := range group {
+			key, value := pair[0], pair[1]
+			if !drop[key] {
+				continue
+			}
+			if value.Line != key.Line || value.Kind == yaml.MappingNode || value.Kind == yaml.SequenceNode {
+				return content, false
+			}
+			start, valueStart := offset(key), offset(value)
+			if start < 0 || valueStart < 0 {
+				return content, false
+			}
+			lineStart := lineStarts[key.Line-1]
+			flow := flowDepth(content[lineStart:start]) > 0
+			end := redactValueEnd(content, valueStart, flow)
+			if value.Kind == yaml.AliasNode {
+				end = valueStart + 1 + len(value.Value)
+			}
+			if end <= valueStart {
+				return content, false
+			}
+			if flow {
+				s, e := start, end
+				if i := strings.LastIndexAny(content[lineStart:start], ",{"); i >= 0 && content[lineStart+i] == ',' {
+					s = lineStart + i
+				} else if j := strings.IndexByte(content[end:], ','); j >= 0 && strings.TrimSpace(content[end:end+j]) == "" {
+					e = end + j + 1
+					for e < len(content) && (content[e] == ' ' || content[e] == '\t') {
+						e++
+					}
+				} else {
+					return content, false
+				}
+				spans = append(spans, span{s, e})
+				continue
+			}
+			lineEnd := len(content)
+			if i := strings.IndexByte(content[end:], '\n'); i >= 0 {
+				lineEnd = end + i + 1
+			}
+			rest := strings.TrimSpace(content[end:lineEnd])
+			if strings.TrimSpace(content[lineStart:start]) != "" || rest != "" && !strings.HasPrefix(rest, "#") {
+				return content, false
+			}
+			spans = append(spans, span{lineStart, lineEnd})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start > spans[j].start })
+	for _, s := range spans {
+		content = content[:s.start] + content[s.end:]
+	}
+	return content, true
+}
+
+// printKeyDuplicates выводит прокси, у которых убраны повторы полей (-dedup-keys)
+func printKeyDuplicates(dups []keyDuplicate, opts *options, indent string) {
+	if len(dups) == 0 {
+		return
+	}
+	sayf("%s🧹 Исправлено прокси с повторяющимися полями (-dedup-keys %s): %d\n", indent, opts.DedupKeys, countKeyProxies(dups))
+	for _, dup := range dups {
+		note := ""
+		if dup.Differs {
+			note = ", значения отличались"
+		}
+		sayf("%s   • %s: %s (строки %s%s)\n", indent, dup.Name, dup.Key, joinLines(dup.Lines), note)
+	}
+}
+
+// countKeyProxies считает прокси с повторами полей: у одного прокси
+// может повторяться несколько полей
+func countKeyProxies(dups []keyDuplicate) int {
+	lines := map[int]bool{}
+	for _, dup := range dups {
+		lines[dup.Line] = true
+	}
+	return len(lines)
+}
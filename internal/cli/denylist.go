@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// serverList — список серверов: точные имена хостов и подсети CIDR
+type serverList struct {
+	hosts map[string]bool
+	nets  []*net.IPNet
+}
+
+// loadServerList читает список серверов из файла: по одному имени хоста
+// или подсети CIDR на строку, пустые строки и комментарии (#) пропускаются
+func loadServerList(path string) (*serverList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list := &serverList{hosts: map[string]bool{}}
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "/") {
+			_, ipNet, err := net.ParseCIDR(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: неверная подсеть %q", path, lineNo, line)
+			}
+			list.nets = append(list.nets, ipNet)
+			continue
+		}
+		list.hosts[strings.ToLower(line)] = true
+	}
+	return list, scanner.Err()
+}
+
+// matches проверяет, входит ли сервер в список
+func (l *serverList) matches(server string) bool {
+	if l == nil || server == "" {
+		return false
+	}
+	server = strings.ToLower(strings.Trim(server, "[]"))
+	if l.hosts[server] {
+		return true
+	}
+	if ip := net.ParseIP(server); ip != nil {
+		for _, ipNet := range l.nets {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,350 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp — одна операция построчного сравнения
+type diffOp struct {
+	kind byte // ' ' — без изменений, '-' — удалено, '+' — добавлено
+	line string
+}
+
+// splitLines разбивает текст на строки, сохраняя символ перевода строки
+// в конце каждой строки (последняя строка может быть без него)
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// myersLimit — предельный размер участка (квадрат суммы длин), для которого
+// используется точный алгоритм Майерса; большие участки без общих
+// уникальных строк сравниваются построчно
+const myersLimit = 1 << 20
+
+// diffLines строит построчный сценарий правки от a к b: общие уникальные
+// строки служат опорными точками (patience diff), а участки между ними
+// сравниваются алгоритмом Майерса
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+
+	// Общие начало и конец
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+	s := 0
+	for s < len(a)-p && s < len(b)-p && a[len(a)-1-s] == b[len(b)-1-s] {
+		s++
+	}
+
+	for _, line := range a[:p] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[p:len(a)-s], b[p:len(b)-s])...)
+	for _, line := range a[len(a)-s:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// diffMiddle сравнивает участок без общих начала и конца
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a) == 0 || len(b) == 0 {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	anchors := uniqueAnchors(a, b)
+	if len(anchors) == 0 {
+		if size := len(a) + len(b); size*size <= myersLimit {
+			return myersDiff(a, b)
+		}
+		// Чередуем удаленные и добавленные строки: так замены строк
+		// (типичный случай для компактного формата) идут парами
+		for i := 0; i < len(a) || i < len(b); i++ {
+			if i < len(a) {
+				ops = append(ops, diffOp{'-', a[i]})
+			}
+			if i < len(b) {
+				ops = append(ops, diffOp{'+', b[i]})
+			}
+		}
+		return ops
+	}
+
+	ai, bi := 0, 0
+	for _, anchor := range anchors {
+		ops = append(ops, diffLines(a[ai:anchor[0]], b[bi:anchor[1]])...)
+		ops = append(ops, diffOp{' ', a[anchor[0]]})
+		ai, bi = anchor[0]+1, anchor[1]+1
+	}
+	return append(ops, diffLines(a[ai:], b[bi:])...)
+}
+
+// uniqueAnchors находит строки, встречающиеся ровно один раз и в a, и в b,
+// и возвращает наибольшую цепочку пар позиций, возрастающую в обоих текстах
+func uniqueAnchors(a, b []string) [][2]int {
+	countA := make(map[string]int, len(a))
+	posA := make(map[string]int, len(a))
+	for i, line := range a {
+		countA[line]++
+		posA[line] = i
+	}
+	countB := make(map[string]int, len(b))
+	for _, line := range b {
+		countB[line]++
+	}
+
+	// Кандидаты в порядке b; позиции в a нужно упорядочить (LIS)
+	var pairs [][2]int
+	for j, line := range b {
+		if countB[line] == 1 && countA[line] == 1 {
+			pairs = append(pairs, [2]int{posA[line], j})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	// Наибольшая возрастающая подпоследовательность по позиции в a
+	tails := []int{}
+	prev := make([]int, len(pairs))
+	for i, pair := range pairs {
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if pairs[tails[mid]][0] < pair[0] {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		} else {
+			prev[i] = -1
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+
+	result := make([][2]int, len(tails))
+	for i, k := len(tails)-1, tails[len(tails)-1]; i >= 0; i, k = i-1, prev[k] {
+		result[i] = pairs[k]
+	}
+	return result
+}
+
+// myersDiff строит кратчайший сценарий правки (алгоритм Майерса)
+func myersDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+
+	// v[k+offset] — наибольший x на диагонали k
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				return backtrackDiff(a, b, trace, d, offset)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrackDiff восстанавливает операции по сохраненным состояниям
+func backtrackDiff(a, b []string, trace [][]int, d, offset int) []diffOp {
+	x, y := len(a), len(b)
+	var ops []diffOp
+
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+offset]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{' ', a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{'-', a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, diffOp{' ', a[x]})
+	}
+
+	// Операции собраны с конца — разворачиваем
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// unifiedDiff возвращает изменения от a к b в формате unified diff,
+// который понимает утилита patch. Пустая строка — изменений нет.
+func unifiedDiff(fromName, toName, a, b string, context int) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	if !hasChanges(ops) {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n", fromName)
+	fmt.Fprintf(&sb, "+++ %s\n", toName)
+
+	aLine, bLine := opLines(ops)
+	for _, h := range diffHunks(ops, context) {
+		aCount, bCount := 0, 0
+		for _, op := range ops[h.start:h.end] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aLine[h.start], aCount), hunkRange(bLine[h.start], bCount))
+
+		for _, op := range ops[h.start:h.end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// hasChanges сообщает, что среди операций есть изменения
+func hasChanges(ops []diffOp) bool {
+	for _, op := range ops {
+		if op.kind != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// opLines возвращает номера строк (с 1) в a и b для начала каждой операции
+func opLines(ops []diffOp) ([]int, []int) {
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	aLine[0], bLine[0] = 1, 1
+	for i, op := range ops {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if op.kind != '+' {
+			aLine[i+1]++
+		}
+		if op.kind != '-' {
+			bLine[i+1]++
+		}
+	}
+	return aLine, bLine
+}
+
+// diffHunk — блок diff: операции ops[start:end] с окружением
+type diffHunk struct{ start, end int }
+
+// diffHunks делит операции на блоки: изменения, разделенные не более чем
+// 2*context неизмененными строками, объединяются в один блок
+func diffHunks(ops []diffOp, context int) []diffHunk {
+	var hunks []diffHunk
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				if run-end < context {
+					end = run
+				} else {
+					end += context
+				}
+				break
+			}
+			end = run
+		}
+		hunks = append(hunks, diffHunk{start, end})
+		i = end
+	}
+	return hunks
+}
+
+// hunkRange форматирует диапазон строк заголовка блока
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// Для пустого диапазона указывается строка перед ним
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
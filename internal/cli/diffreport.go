@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// diffArchive — diff, сохраняемый в файл (-report-diff): по части на
+// каждый измененный входной файл
+type diffArchive struct {
+	parts []diffPart
+}
+
+// diffPart — diff одного входного файла
+type diffPart struct {
+	Rel  string // путь для имени файла с -report-diff-split
+	Diff string
+}
+
+// add добавляет diff входного файла path. В заголовках "---" и "+++"
+// стоит сам входной файл, а не результат: так patch -p0 однозначно
+// применяет diff к исходному файлу. Значения пишутся как есть, без
+// -redact: иначе diff нельзя применить.
+func (d *diffArchive) add(rel, path, a, b string, opts *options) {
+	if diff := unifiedDiff(path, path, a, b, opts.Context); diff != "" {
+		d.parts = append(d.parts, diffPart{Rel: rel, Diff: diff})
+	}
+}
+
+// text возвращает общий diff всех файлов
+func (d *diffArchive) text() string {
+	var sb strings.Builder
+	for _, part := range d.parts {
+		sb.WriteString(part.Diff)
+	}
+	return sb.String()
+}
+
+// diffTarget возвращает файл для -report-diff: для папки (существующей
+// или с '/' в конце) — err_x509-<время>.diff в ней
+func diffTarget(path string, now time.Time) string {
+	if isDirTarget(path) {
+		return filepath.Join(path, "err_x509-"+now.Format("20060102-150405")+".diff")
+	}
+	return path
+}
+
+// splitDir — папка для diff по файлам (-report-diff-split)
+func splitDir(opts *options, now time.Time) string {
+	return filepath.Join(opts.ReportDiff, "err_x509-"+now.Format("20060102-150405"))
+}
+
+// isDirTarget сообщает, что -report-diff указывает на папку
+func isDirTarget(path string) bool {
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// write записывает diff в -report-diff: одним файлом, который применяется
+// командой patch -p0 из папки запуска, или, с -report-diff-split, по файлу
+// <путь>.diff на каждый входной файл в папке err_x509-<время>. Возвращает
+// записанные файлы; без изменений ничего не пишется.
+func (d *diffArchive) write(opts *options, now time.Time) ([]string, error) {
+	if len(d.parts) == 0 {
+		return nil, nil
+	}
+	if opts.ReportDiffSplit {
+		dir := splitDir(opts, now)
+		var written []string
+		for _, part := range d.parts {
+			path := filepath.Join(dir, part.Rel+".diff")
+			if err := writeFileMkdir(path, []byte(part.Diff), opts.FileMode); err != nil {
+				return written, err
+			}
+			written = append(written, path)
+		}
+		return written, nil
+	}
+	path := diffTarget(opts.ReportDiff, now)
+	if err := writeFileMkdir(path, []byte(d.text()), opts.FileMode); err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+// saveDiff записывает -report-diff, если он задан, и выводит результат
+func saveDiff(opts *options, diffs *diffArchive, indent string) error {
+	if opts.ReportDiff == "" {
+		return nil
+	}
+	now := opts.now()
+	written, err := diffs.write(opts, now)
+	if err != nil {
+		return err
+	}
+	if len(written) == 0 {
+		sayf("%s📝 -report-diff: изменений нет, diff не записан\n", indent)
+		return nil
+	}
+	if opts.ReportDiffSplit {
+		sayf("%s📝 Diff сохранен по файлам: %d в %s\n", indent, len(written), splitDir(opts, now))
+		return nil
+	}
+	sayf("%s📝 Diff сохранен: %s\n", indent, written[0])
+	return nil
+}
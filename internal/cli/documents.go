@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// documentStarts возвращает смещения начала документов YAML в тексте:
+// документы разделяются строками "---" с начала строки. Текст перед
+// первым разделителем — документ, только если в нем есть что-то, кроме
+// комментариев, пустых строк и директив %YAML.
+func documentStarts(content string) []int {
+	var starts []int
+	pending := true // с начала текста еще не было ничего, кроме комментариев
+	for pos := 0; pos < len(content); {
+		next := len(content)
+		if i := strings.IndexByte(content[pos:], '\n'); i >= 0 {
+			next = pos + i + 1
+		}
+		line := strings.TrimRight(content[pos:next], "\r\n")
+		switch {
+		case line == "---" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "---\t"):
+			starts = append(starts, next)
+			pending = false
+		case pending:
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "%") {
+				starts = append(starts, 0)
+				pending = false
+			}
+		}
+		pos = next
+	}
+	if len(starts) == 0 {
+		starts = append(starts, 0)
+	}
+	return starts
+}
+
+// splitDocument делит текст вокруг документа n (с 1, -document): before
+// включает строку "---" перед документом, after начинается со строки
+// "---" после него
+func splitDocument(content string, n int) (before, block, after string, err error) {
+	starts := documentStarts(content)
+	if n > len(starts) {
+		return "", "", "", fmt.Errorf("-document %d: в файле документов YAML: %d", n, len(starts))
+	}
+	start := starts[n-1]
+	end := len(content)
+	if n < len(starts) {
+		// Следующий документ начинается после своей строки "---"
+		end = strings.LastIndexByte(content[:starts[n]-1], '\n') + 1
+	}
+	return content[:start], content[start:end], content[end:], nil
+}
+
+// processDocument обрабатывает весь текст или, с -document N, только
+// документ N: остальные документы остаются как есть, номера строк
+// в результате считаются от начала файла
+func processDocument(content string, opts *options) (fixResult, error) {
+	if opts.Document == 0 {
+		return processContent(content, opts)
+	}
+	before, block, after, err := splitDocument(content, opts.Document)
+	if err != nil {
+		return fixResult{Content: content}, err
+	}
+	res, err := processContent(block, opts)
+	if err != nil {
+		return res, err
+	}
+	res.shiftLines(strings.Count(before, "\n"))
+	res.Content = before + res.Content + after
+	return res, nil
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// textEncoding — кодировка входного файла, в которой записывается результат
+type textEncoding struct {
+	Name  string
+	bom   []byte
+	codec encoding.Encoding // nil — UTF-8
+}
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// errNotUTF8 — файл без BOM, который не является корректным UTF-8
+var errNotUTF8 = errors.New("файл не в кодировке UTF-8 и не содержит BOM; " +
+	"сохраните его в UTF-8 или UTF-16 с BOM")
+
+// decodeInput определяет кодировку по BOM и возвращает текст в UTF-8 без BOM.
+// UTF-16 LE/BE перекодируется; файл без BOM должен быть корректным UTF-8.
+func decodeInput(data []byte) (string, textEncoding, error) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return string(data[len(bomUTF8):]), textEncoding{Name: "UTF-8 BOM", bom: bomUTF8}, nil
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decodeUTF16(data, textEncoding{Name: "UTF-16LE",
+			codec: unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)})
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decodeUTF16(data, textEncoding{Name: "UTF-16BE",
+			codec: unicode.UTF16(unicode.BigEndian, unicode.UseBOM)})
+	}
+	if !utf8.Valid(data) {
+		return "", textEncoding{}, errNotUTF8
+	}
+	return string(data), textEncoding{Name: "UTF-8"}, nil
+}
+
+// decodeUTF16 перекодирует UTF-16 с BOM в UTF-8 (BOM удаляется)
+func decodeUTF16(data []byte, enc textEncoding) (string, textEncoding, error) {
+	text, err := enc.codec.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", enc, err
+	}
+	return string(text), enc, nil
+}
+
+// encodeOutput записывает текст в исходной кодировке файла (с тем же BOM)
+func encodeOutput(content string, enc textEncoding) ([]byte, error) {
+	if enc.codec != nil {
+		return enc.codec.NewEncoder().Bytes([]byte(content))
+	}
+	return append(append([]byte{}, enc.bom...), content...), nil
+}
+
+// isUTF8 проверяет, что файл в обычном UTF-8 без BOM
+func (e textEncoding) isUTF8() bool {
+	return e.codec == nil && e.bom == nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Форматы строк с ошибками проверки сертификата, из которых извлекается хост
+var (
+	// Начало текста ошибки сертификата
+	errCertPattern = regexp.MustCompile(`(?i)tls: failed to verify certificate|x509:|certificate verify failed`)
+	// x509: certificate is valid for a.com, b.com, not host
+	errNotHostPattern = regexp.MustCompile(`valid for .*?, not ([^\s,;:"')]+)`)
+	// x509: certificate is not valid for any names, but wanted to match host
+	errWantedPattern = regexp.MustCompile(`wanted to match ([^\s,;:"')]+)`)
+	// Get "https://host:443/path": x509: ... — адрес запроса прямо перед ошибкой
+	errURLPattern = regexp.MustCompile(`[a-z]+://(\[[0-9A-Fa-f:.]+\]|[^\s/:"'\]]+)[^\s"]*"?:\s*$`)
+	// host:443 connect error: tls: ... — адрес соединения прямо перед ошибкой
+	errHostPortPattern = regexp.MustCompile(`(?:^|[\s(=>"'])(\[[0-9A-Fa-f:.]+\]|[A-Za-z0-9][A-Za-z0-9.-]*\.[A-Za-z0-9-]+):\d{1,5}:?(?:\s+[a-z]+)*:?\s*$`)
+)
+
+// isCertErrorLine проверяет, что строка журнала сообщает об ошибке сертификата
+func isCertErrorLine(line string) bool {
+	return errCertPattern.MatchString(line)
+}
+
+// errorLogHosts извлекает имена хостов из строки с ошибкой сертификата:
+// из текста ошибки (valid for ..., not host; wanted to match host) и адрес,
+// к которому ошибка относится, прямо перед ней. Остальные адреса строки,
+// например цель соединения (--> google.com:443), не берутся.
+func errorLogHosts(line string) []string {
+	loc := errCertPattern.FindStringIndex(line)
+	if loc == nil {
+		return nil
+	}
+	text, before := line[loc[0]:], line[:loc[0]]
+	var hosts []string
+	for _, pattern := range []*regexp.Regexp{errNotHostPattern, errWantedPattern} {
+		for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+			hosts = append(hosts, strings.TrimRight(m[1], "."))
+		}
+	}
+	for _, pattern := range []*regexp.Regexp{errURLPattern, errHostPortPattern} {
+		m := pattern.FindStringSubmatchIndex(before)
+		if m == nil || strings.HasSuffix(strings.TrimRight(before[:m[2]], " "), "-->") {
+			continue
+		}
+		hosts = append(hosts, strings.TrimRight(before[m[2]:m[3]], "."))
+	}
+	return hosts
+}
+
+// loadErrorLog читает журнал клиента (-error-log) и собирает хосты из строк
+// с ошибками проверки сертификата x509
+func loadErrorLog(path string) (*serverList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list := &serverList{hosts: map[string]bool{}}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !isCertErrorLine(line) {
+			continue
+		}
+		for _, host := range errorLogHosts(line) {
+			list.hosts[strings.ToLower(strings.Trim(host, "[]"))] = true
+		}
+	}
+	return list, scanner.Err()
+}
+
+// matchedProxies возвращает имена прокси, чей сервер встречается в -error-log
+func matchedProxies(proxies []proxyStatus, hosts *serverList) []string {
+	var names []string
+	for _, p := range proxies {
+		if hosts.matches(p.Server) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestLoadErrorLog проверяет, что из журнала берутся только хосты ошибок
+// сертификата: цель соединения (google.com) и строки без ошибки
+// (d.example.com) не попадают
+func TestLoadErrorLog(t *testing.T) {
+	list, err := loadErrorLog("testdata/x509_errors.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for host := range list.hosts {
+		got = append(got, host)
+	}
+	sort.Strings(got)
+	want := []string{"10.0.0.5", "a.example.com", "b.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("хосты %q, want %q", got, want)
+	}
+}
+
+func TestErrorLogHosts(t *testing.T) {
+	cases := []struct {
+		line string
+		want []string
+	}{
+		{`dial DIRECT --> [2001:db8::2]:443 error: [2001:db8::1]:443 connect error: tls: failed to verify certificate: x509: certificate signed by unknown authority`,
+			[]string{"[2001:db8::1]"}},
+		{`Get "https://api.example.com:8443/v1": tls: failed to verify certificate: x509: certificate is valid for other.com, not api.example.com`,
+			[]string{"api.example.com", "api.example.com"}},
+		{`proxy c.example.com:443: x509: certificate has expired`, []string{"c.example.com"}},
+		{`--> google.com:443 error: x509: certificate signed by unknown authority`, nil},
+		{`--> google.com:443 ok`, nil},
+	}
+	for _, c := range cases {
+		if got := errorLogHosts(c.line); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: %q, want %q", c.line, got, c.want)
+		}
+	}
+}
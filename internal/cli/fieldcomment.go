@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fieldComment возвращает комментарий -field-comment к добавленному полю
+// в виде "# текст"; пустая строка — без комментария
+func (o *options) fieldComment() string {
+	text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(o.FieldComment), "#"))
+	if text == "" {
+		return ""
+	}
+	return "# " + text
+}
+
+// commentCompact добавляет комментарий -field-comment после записи
+// компактного формата, если запись заканчивает строку: комментарий внутри
+// { } разорвал бы запись, а уже стоящий после нее комментарий остается
+// единственным
+func commentCompact(content string, entry proxyEntry, fixed, comment string) string {
+	rest := content[entry.End:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i]
+	}
+	if comment == "" || strings.TrimSpace(rest) != "" {
+		return fixed
+	}
+	return fixed + " " + comment
+}
+
+// flowOnPath проверяет, что на пути -placement внутри прокси есть запись
+// в потоковом стиле { ... }: туда комментарий тоже не ставится
+func flowOnPath(item *yaml.Node, path string) bool {
+	node := item
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		if node = mappingValue(node, key); node == nil || node.Kind != yaml.MappingNode {
+			return false
+		}
+		if node.Style&yaml.FlowStyle != 0 {
+			return true
+		}
+	}
+	return false
+}
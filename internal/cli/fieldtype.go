@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Типы значения добавляемого поля (-field-type)
+const (
+	fieldAuto   = "auto"   // как есть, тип определит YAML; true/false — логическое
+	fieldBool   = "bool"   // логическое true/false
+	fieldString = "string" // строка, в кавычках, если иначе YAML прочитает ее не строкой
+	fieldInt    = "int"    // целое число
+)
+
+// checkFieldValue проверяет, что значение подходит под тип, и нормализует его
+func checkFieldValue(value, fieldType string) (string, error) {
+	switch fieldType {
+	case fieldAuto, fieldString:
+		return value, nil
+	case fieldBool:
+		if b, ok := parseBool(value); ok {
+			return b, nil
+		}
+		return "", fmt.Errorf("значение %q не логическое (true/false)", value)
+	case fieldInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("значение %q не целое число", value)
+		}
+		return strconv.FormatInt(n, 10), nil
+	}
+	return "", fmt.Errorf("неизвестный тип %q (допустимо: auto, bool, string, int)", fieldType)
+}
+
+// typedValue нормализует значение по -field-type. Значение, которое под тип
+// не подходит (например, из комментария x509:value), пишется как есть.
+func typedValue(value, fieldType string) (string, string) {
+	if v, err := checkFieldValue(value, fieldType); err == nil {
+		return v, fieldType
+	}
+	return value, fieldAuto
+}
+
+// formatValue возвращает значение поля для вставки в текст конфига.
+// Строка берется в двойные кавычки, если без них YAML прочитает ее
+// не как строку или она сломает компактную запись { ... }.
+func formatValue(value, fieldType string) string {
+	if fieldType != fieldString || isPlainString(value) {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// isPlainString проверяет, что строку можно записать без кавычек
+// и в многострочной, и в компактной записи
+func isPlainString(value string) bool {
+	if value == "" || value != strings.TrimSpace(value) || strings.ContainsAny(value, ",[]{}#&*!|>'\"%@`:\\\t\r\n") {
+		return false
+	}
+	if strings.ContainsAny(value[:1], "-?") {
+		return false
+	}
+	// Логические значения YAML 1.1: их читают как true/false старые парсеры
+	switch strings.ToLower(value) {
+	case "y", "yes", "n", "no", "on", "off":
+		return false
+	}
+	var decoded interface{}
+	if err := yaml.Unmarshal([]byte(value), &decoded); err != nil {
+		return false
+	}
+	s, ok := decoded.(string)
+	return ok && s == value
+}
+
+// valueNode возвращает узел значения поля с тегом по -field-type.
+// Кавычки у строк ставятся по тем же правилам, что и в formatValue.
+func valueNode(value, fieldType string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	switch fieldType {
+	case fieldString:
+		node.Tag = "!!str"
+		if !isPlainString(value) {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	case fieldInt:
+		node.Tag = "!!int"
+	case fieldBool:
+		node.Tag = "!!bool"
+	default:
+		if value == "true" || value == "false" {
+			node.Tag = "!!bool"
+		}
+	}
+	return node
+}
@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// tempPattern — имя временного файла для атомарной записи
+const tempPattern = ".err_x509-*.tmp"
+
+// staleTempAge — временный файл старше этого считается оставшимся после сбоя;
+// более свежий может принадлежать другому запуску, который еще пишет
+const staleTempAge = time.Minute
+
+// backupSuffix возвращает расширение резервной копии для формата
+func backupSuffix(format string) string {
+	if format == "patch" {
+		return ".patch"
+	}
+	return ".backup"
+}
+
+// buildBackup возвращает содержимое резервной копии: байты исходного файла
+// как есть или патч, восстанавливающий их из результата (patch -R). Патч
+// строится по байтам файлов на диске: исходным raw и записанному
+// результату fixed в кодировке enc, так что BOM и переводы строк
+// в нем те же, что в файлах.
+func buildBackup(format, inputName, outputName string, raw []byte, fixed string, enc textEncoding) []byte {
+	if format != "patch" {
+		return raw
+	}
+	written, err := encodeOutput(fixed, enc)
+	if err != nil {
+		return raw
+	}
+	return []byte(unifiedDiff(inputName, outputName, string(raw), string(written), 3))
+}
+
+// backupFormatFor возвращает формат резервной копии для файла: патч
+// по байтам на диске не построить для UTF-16 и для подписки, распакованной
+// -decode auto (layers), — вместо него сохраняется полная копия
+func backupFormatFor(format string, enc textEncoding, layers []string) string {
+	if format == "patch" && (enc.codec != nil || len(layers) > 0) {
+		return "copy"
+	}
+	return format
+}
+
+// patchFallback выбирает формат резервной копии файла (backupFormatFor)
+// и возвращает ее путь: если патч заменен копией, с расширением копии
+func patchFallback(path string, opts *options, enc textEncoding, layers []string) (string, string) {
+	format := backupFormatFor(opts.BackupFormat, enc, layers)
+	if format == opts.BackupFormat {
+		return path, format
+	}
+	return strings.TrimSuffix(path, backupSuffix(opts.BackupFormat)) + backupSuffix(format), format
+}
+
+// resolveTarget возвращает файл, в который на самом деле идет запись:
+// для символической ссылки — файл, на который она указывает
+func resolveTarget(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return path, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return path, nil
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) {
+		// Ссылка на файл, которого еще нет: он создается там, куда она
+		// указывает (папку создает -mkdir)
+		if link, linkErr := os.Readlink(path); linkErr == nil {
+			if !filepath.IsAbs(link) {
+				link = filepath.Join(filepath.Dir(path), link)
+			}
+			return link, nil
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("символическая ссылка %s указывает на недоступный файл: %w", path, err)
+	}
+	return target, nil
+}
+
+// checkSymlink запрещает символические ссылки с -no-follow-symlinks
+func checkSymlink(path string, opts *options) error {
+	if !opts.NoFollowSymlinks {
+		return nil
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%s — символическая ссылка (-no-follow-symlinks)", path)
+	}
+	return nil
+}
+
+// writeFile записывает файл атомарно: данные пишутся во временный файл рядом
+// с целью и переименовываются поверх нее. Символическая ссылка сохраняется,
+// изменяется файл, на который она указывает. Права существующего файла
+// сохраняются, если не задан perm (-chmod, -out-permissions-from).
+// В именованный канал (FIFO) и другой необычный файл переименовать нельзя:
+// в них данные пишутся напрямую.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	target, err := resolveTarget(path)
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(target); err == nil {
+		if !info.Mode().IsRegular() {
+			return writeDirect(target, data)
+		}
+		mode = info.Mode().Perm()
+	}
+	if perm != 0 {
+		mode = perm
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), tempPattern)
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// writeDirect записывает данные в существующий необычный файл (FIFO,
+// устройство) без временного файла; права такого файла не меняются
+func writeDirect(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writePreview записывает результат пробного запуска в файл -dry-run-out
+// в кодировке входного файла. Путь не должен совпадать с входным файлом.
+func writePreview(opts *options, input, content string, enc textEncoding) error {
+	if opts.DryRunOut == "" {
+		return nil
+	}
+	if sameFile(opts.DryRunOut, input) {
+		return fmt.Errorf("%s — это входной файл", opts.DryRunOut)
+	}
+	out, err := encodeOutput(content, enc)
+	if err != nil {
+		return err
+	}
+	if err := writeFileMkdir(opts.DryRunOut, out, opts.FileMode); err != nil {
+		return err
+	}
+	sayf("👀 Результат для просмотра: %s\n", opts.DryRunOut)
+	return nil
+}
+
+// sameFile проверяет, что пути указывают на один и тот же существующий файл
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// readPrevious возвращает прежний результат для -backup-mode previous-output;
+// false — результата еще нет или это не обычный файл (FIFO)
+func readPrevious(path string) ([]byte, bool) {
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	return data, err == nil
+}
+
+// writeFileMkdir записывает файл, создавая недостающие папки (для
+// символической ссылки — папки файла, на который она указывает)
+func writeFileMkdir(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(targetDir(path), 0755); err != nil {
+		return err
+	}
+	return writeFile(path, data, perm)
+}
+
+// writeResult записывает результат или копию однофайлового режима;
+// с -mkdir недостающие папки создаются
+func writeResult(opts *options, path string, data []byte) error {
+	if opts.Mkdir {
+		return writeFileMkdir(path, data, opts.FileMode)
+	}
+	return writeFile(path, data, opts.FileMode)
+}
+
+// checkWritable заранее проверяет, что файл path можно будет записать:
+// его папка существует (или, с mkdir, создается в существующей) и в ней
+// создается временный файл. Так права, файловая система только для чтения
+// и несуществующая папка выявляются до обработки, а не при записи.
+func checkWritable(path string, mkdir bool) error {
+	if info, err := os.Stat(path); err == nil && !info.Mode().IsRegular() {
+		return nil // FIFO и устройства пишутся напрямую (writeDirect)
+	}
+	dir := targetDir(path)
+	probe := dir
+	for {
+		info, err := os.Stat(probe)
+		if err == nil && !info.IsDir() {
+			return fmt.Errorf("%s — не папка", probe)
+		}
+		if err == nil {
+			break
+		}
+		parent := filepath.Dir(probe)
+		if !os.IsNotExist(err) || parent == probe {
+			return err
+		}
+		probe = parent
+	}
+	if probe != dir && !mkdir {
+		return fmt.Errorf("папки %s нет (создать ее: -mkdir)", dir)
+	}
+
+	tmp, err := os.CreateTemp(probe, tempPattern)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return fmt.Errorf("в папку %s нельзя записывать: %w", probe, err)
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+	return nil
+}
+
+// isTempName проверяет, что имя создано os.CreateTemp по tempPattern:
+// префикс, суффикс и только цифры между ними
+func isTempName(name string) bool {
+	prefix, suffix, _ := strings.Cut(tempPattern, "*")
+	middle := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+	if len(middle) == 0 || len(middle)+len(prefix)+len(suffix) != len(name) {
+		return false
+	}
+	return strings.Trim(middle, "0123456789") == ""
+}
+
+// cleanStaleTemps удаляет временные файлы, оставшиеся в папках dirs после
+// прерванной записи. Удаляются только обычные файлы с именем по tempPattern
+// старше staleTempAge; в пробном запуске они только перечисляются.
+func cleanStaleTemps(dirs []string, dryRun bool) {
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		matches, _ := filepath.Glob(filepath.Join(dir, tempPattern))
+		for _, path := range matches {
+			info, err := os.Lstat(path)
+			if err != nil || !info.Mode().IsRegular() || !isTempName(info.Name()) ||
+				time.Since(info.ModTime()) < staleTempAge {
+				continue
+			}
+			if dryRun {
+				sayf("🧹 Найден временный файл прерванного запуска: %s\n", path)
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				sayf("⚠️  Не удалось удалить временный файл %s: %v\n", path, err)
+				continue
+			}
+			sayf("🧹 Удален временный файл прерванного запуска: %s\n", path)
+		}
+	}
+}
+
+// targetDir возвращает папку, в которой writeFile создает временный файл
+func targetDir(path string) string {
+	if target, err := resolveTarget(path); err == nil {
+		path = target
+	}
+	return filepath.Dir(path)
+}
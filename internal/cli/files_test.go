@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupFormatFor(t *testing.T) {
+	utf16, err := os.ReadFile("testdata/utf16le.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, utf16Enc, err := decodeInput(utf16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, bomEnc, _ := decodeInput(append(append([]byte{}, bomUTF8...), "proxies: []\n"...))
+	cases := []struct {
+		name   string
+		format string
+		enc    textEncoding
+		layers []string
+		want   string
+	}{
+		{"utf8", "patch", textEncoding{Name: "UTF-8"}, nil, "patch"},
+		{"utf8 bom", "patch", bomEnc, nil, "patch"},
+		{"utf16", "patch", utf16Enc, nil, "copy"},
+		{"decode auto", "patch", textEncoding{Name: "UTF-8"}, []string{"base64"}, "copy"},
+		{"copy", "copy", utf16Enc, []string{"base64"}, "copy"},
+	}
+	for _, c := range cases {
+		if got := backupFormatFor(c.format, c.enc, c.layers); got != c.want {
+			t.Errorf("%s: backupFormatFor(%s) = %s, want %s", c.name, c.format, got, c.want)
+		}
+	}
+}
+
+// TestPatchBackupRestores проверяет, что patch -R по резервной копии
+// восстанавливает исходные байты, в том числе BOM и CRLF
+func TestPatchBackupRestores(t *testing.T) {
+	patchTool, err := exec.LookPath("patch")
+	if err != nil {
+		t.Skip("нет утилиты patch")
+	}
+	inputs := map[string]string{
+		"plain.yaml": "proxies:\n  - { name: a, type: trojan, server: a.com, port: 443 }\n",
+		"bom.yaml":   "\xef\xbb\xbfproxies:\n  - { name: a, type: trojan, server: a.com, port: 443 }\n",
+		"crlf.yaml":  "proxies:\r\n  - name: a\r\n    type: trojan\r\n    server: a.com\r\n    port: 443\r\n",
+	}
+	opts := testOptions(t, "-backup-format", "patch")
+	for name, input := range inputs {
+		raw := []byte(input)
+		original, enc, layers, err := readInput(raw, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := processContent(original, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if backupFormatFor(opts.BackupFormat, enc, layers) != "patch" {
+			t.Fatalf("%s: патч заменен копией", name)
+		}
+		dir := t.TempDir()
+		output := filepath.Join(dir, name)
+		written, _ := encodeOutput(res.Content, enc)
+		if err := os.WriteFile(output, written, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		backup := filepath.Join(dir, name+".patch")
+		if err := os.WriteFile(backup, buildBackup("patch", name, name, raw, res.Content, enc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command(patchTool, "-s", "-R", output, backup).CombinedOutput(); err != nil {
+			t.Fatalf("%s: patch -R: %v\n%s", name, err, out)
+		}
+		restored, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(restored) != input {
+			t.Errorf("%s: восстановлено %q, want %q", name, restored, input)
+		}
+	}
+}
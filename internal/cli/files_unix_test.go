@@ -0,0 +1,113 @@
+//go:build unix
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestWriteFileFIFO проверяет запись в именованный канал: данные доходят
+// до читателя, временный файл не остается, канал остается каналом
+func TestWriteFileFIFO(t *testing.T) {
+	for _, perm := range []os.FileMode{0, 0o600} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "x509_fixed.yaml")
+		if err := syscall.Mkfifo(path, 0o644); err != nil {
+			t.Skipf("mkfifo: %v", err)
+		}
+		before, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []byte("proxies:\n  - { name: a, skip-cert-verify: true }\n")
+
+		read := make(chan []byte, 1)
+		go func() {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Errorf("чтение из канала: %v", err)
+			}
+			read <- data
+		}()
+		if err := writeFile(path, want, perm); err != nil {
+			t.Fatalf("writeFile: %v", err)
+		}
+		select {
+		case got := <-read:
+			if string(got) != string(want) {
+				t.Errorf("из канала прочитано %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("данные из канала не дошли за 5 секунд")
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "x509_fixed.yaml" {
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			t.Errorf("в папке %q, want только x509_fixed.yaml", names)
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			t.Errorf("%s больше не канал: %v", path, info.Mode())
+		}
+		if info.Mode().Perm() != before.Mode().Perm() {
+			t.Errorf("права канала изменились: %v, было %v", info.Mode().Perm(), before.Mode().Perm())
+		}
+	}
+}
+
+// TestBatchNoFollowSymlinks проверяет -no-follow-symlinks для файлов из -in:
+// ни ссылка на входе, ни ссылка на месте результата не изменяют файл,
+// на который указывают
+func TestBatchNoFollowSymlinks(t *testing.T) {
+	config := "proxies:\n  - name: a\n    type: trojan\n    server: a.example.com\n    port: 443\n"
+	cases := []struct {
+		name string
+		args func(dir string) []string
+	}{
+		{"вход", func(dir string) []string {
+			link := filepath.Join(dir, "link.yaml")
+			if err := os.Symlink(filepath.Join(dir, "target.yaml"), link); err != nil {
+				t.Skipf("symlink: %v", err)
+			}
+			return []string{"-in-place", "-in", link}
+		}},
+		{"результат", func(dir string) []string {
+			in := filepath.Join(dir, "config.yaml")
+			if err := os.WriteFile(in, []byte(config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(filepath.Join(dir, "target.yaml"), filepath.Join(dir, "config.fixed.yaml")); err != nil {
+				t.Skipf("symlink: %v", err)
+			}
+			return []string{"-in", in}
+		}},
+	}
+	for _, c := range cases {
+		dir := t.TempDir()
+		target := filepath.Join(dir, "target.yaml")
+		if err := os.WriteFile(target, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		opts := testOptions(t, append([]string{"-yes", "-quiet", "-no-follow-symlinks"}, c.args(dir)...)...)
+		if code := runBatch(opts); code == 0 {
+			t.Errorf("%s: код выхода 0, want ненулевой", c.name)
+		}
+		if data, err := os.ReadFile(target); err != nil || string(data) != config {
+			t.Errorf("%s: файл по ссылке изменен: %q, %v", c.name, data, err)
+		}
+	}
+}
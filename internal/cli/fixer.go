@@ -0,0 +1,863 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Виды предупреждений
+const (
+	warnUnknownType   = "unknown-type"   // неизвестный type прокси
+	warnDuplicateName = "duplicate-name" // повторяющееся имя прокси
+	warnMissingRef    = "missing-ref"    // группа ссылается на несуществующий прокси
+	warnParseSkip     = "parse-skip"     // запись в секции прокси не распознана и пропущена
+	warnParseError    = "parse-error"    // YAML не разбирается, проверки пропущены
+	warnMapConflict   = "map-conflict"   // значение прокси расходится с -map
+	warnPlacement     = "placement"      // поле некуда добавить по пути -placement
+	warnUnknownField  = "unknown-field"  // поле, которого нет в таблице proxyTypes (-validate-only)
+	warnUnterminated  = "unterminated"   // запись - { ... } не закрыта до конца файла
+	warnFlavor        = "flavor"         // тип прокси не поддерживается клиентом -flavor
+	warnAliasList     = "alias-list"     // список прокси задан ссылкой на якорь (-alias-list)
+	warnDuplicateKey  = "duplicate-key"  // поле задано в записи прокси несколько раз
+	warnMalformed     = "malformed"      // прокси есть в YAML, но запись в тексте не найдена
+	warnSNIMismatch   = "sni-mismatch"   // sni не совпадает с server при отключенной проверке (-warn-sni-mismatch)
+	warnSNIConflict   = "sni-conflict"   // sni и servername расходятся (-reconcile-sni)
+)
+
+// warning — предупреждение, найденное при обработке конфига
+type warning struct {
+	Kind    string `json:"kind"`
+	Proxy   string `json:"proxy,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// text возвращает предупреждение для вывода: строка, сообщение и прокси
+func (w warning) text() string {
+	msg := w.Message
+	if w.Proxy != "" {
+		msg += " (" + w.Proxy + ")"
+	}
+	if w.Line > 0 {
+		return fmt.Sprintf("Строка %d: %s", w.Line, msg)
+	}
+	return msg
+}
+
+// fixResult — результат обработки конфига
+type fixResult struct {
+	Content    string
+	Format     string      // "compact", "multiline", "mixed" или "" — прокси не найдены
+	Found      int         // записей, найденных в выбранном формате
+	Modified   int         // добавлено skip-cert-verify
+	AlreadyHas int         // уже имели skip-cert-verify
+	Stripped   int         // удалено полей (-keep-fields)
+	Protected  int         // пропущено по списку -deny-servers
+	Skipped    int         // пропущено: сервера нет в -error-log, поле некуда добавить или тип не знает -flavor
+	Filtered   int         // пропущено фильтром -include-name / -exclude-name или условием -field-remove-if
+	Removed    int         // удалено поле (-field-remove-if)
+	NoRequired int         // пропущено: нет поля из -require-field
+	ServerOut  int         // пропущено фильтром -include-server / -exclude-server
+	NoTLS      int         // пропущено: прокси без TLS (-only-type-tls)
+	Anchor     string      // якорь, подключенный вместо поля (-use-anchor)
+	Duplicates []duplicate // удаленные повторы (-dedup-by)
+	KeyDups    []keyDuplicate
+	Warnings   []warning
+	Records    []recordChange
+	Changes    []ProxyChange // изменения по полям, только с -explain
+	Proxies    []proxyStatus
+
+	EmptySection bool           // секции прокси не было, добавлен пустой список (-emit-empty-section)
+	Transformed  map[string]int // изменено прокси шагами -transform, кроме add-skip-cert
+	Bools        int            // значений, приведенных к true/false (-normalize-bools)
+	Blocks       int            // блоков ```yaml в markdown (-markdown)
+	BlocksFixed  int            // из них изменено
+}
+
+// Состояния прокси после обработки
+const (
+	statusModified  = "modified"  // поле добавлено
+	statusAlready   = "already"   // поле уже было
+	statusProtected = "protected" // пропущен по списку -deny-servers
+	statusSkipped   = "skipped"   // пропущен: сервера нет в -error-log, поле некуда добавить или тип не знает -flavor
+	statusFiltered  = "filtered"  // пропущен фильтром -include-name / -exclude-name
+	statusRemoved   = "removed"   // поле удалено (-field-remove-if)
+	statusDuplicate = "duplicate" // прокси удален как повтор (-dedup-by)
+	statusNoField   = "no-field"  // пропущен: нет поля из -require-field
+
+	// Пропущен фильтром -include-server / -exclude-server
+	statusServerOut = "server-filtered"
+	// Пропущен: прокси без TLS (-only-type-tls)
+	statusNoTLS = "no-tls"
+)
+
+// total возвращает число обработанных прокси
+func (r *fixResult) total() int {
+	return r.Modified + r.AlreadyHas + r.Protected + r.Skipped + r.Filtered + r.Removed + r.NoRequired + r.ServerOut + r.NoTLS
+}
+
+// proxyStatus — итог обработки одного прокси
+type proxyStatus struct {
+	Name   string
+	Type   string
+	Server string
+	Line   int
+	Status string
+	Value  string // примененное значение (или *якорь) для измененных прокси
+
+	FromMarker bool   // значение взято из комментария # x509:value=...
+	FromMap    bool   // значение взято из -map
+	Nested     string // вложенное поле, которое уже настраивает проверку (tls.insecure, ...)
+	TLS        string // почему прокси считается с TLS или без (-only-type-tls)
+}
+
+// recordChange — измененная запись прокси целиком (до и после)
+type recordChange struct {
+	Name   string
+	Line   int
+	Before string
+	After  string
+}
+
+// proxyEntry — запись прокси, найденная в тексте конфига
+type proxyEntry struct {
+	Start, End int // границы записи в байтах
+	Line       int // номер строки начала записи (с 1)
+	Compact    bool
+}
+
+// compactStartPattern — начало записи прокси в компактном формате: - { ... }.
+// Дефис стоит там, где начинается элемент списка: в начале строки после
+// отступа (и после "- " внешнего списка) или после '[' и ',' списка
+// в скобках, так что "password: abc-{def}" записью не считается. Группа 1 —
+// сама запись от дефиса. Конец записи — парная скобка (flowScan):
+// вложенные { } и скобки в кавычках и комментариях запись не обрывают.
+var compactStartPattern = regexp.MustCompile(`(?m)(?:^|[\[,])[ \t]*(?:-[ \t]+)*(-\s*\{)`)
+
+// compactStarts возвращает границы начала компактных записей "- {"
+// в тексте: от дефиса до открывающей скобки включительно
+func compactStarts(content string) [][]int {
+	var starts [][]int
+	for _, m := range compactStartPattern.FindAllStringSubmatchIndex(content, -1) {
+		starts = append(starts, m[2:4])
+	}
+	return starts
+}
+
+// processContent выполняет шаги -transform по порядку; по умолчанию
+// единственный шаг — добавление поля (add-skip-cert)
+func processContent(content string, opts *options) (fixResult, error) {
+	res := fixResult{Content: content}
+	var dups []duplicate
+	if opts.DedupBy != "" {
+		var err error
+		if res.Content, dups, err = dedupProxies(content, opts); err != nil {
+			return res, err
+		}
+	}
+	var keyDups []keyDuplicate
+	if opts.DedupKeys != "" {
+		var err error
+		if res.Content, keyDups, err = dedupKeys(res.Content, opts); err != nil {
+			return res, err
+		}
+	}
+	bools := 0
+	if opts.NormalizeBools {
+		res.Content, bools = normalizeBools(res.Content)
+	}
+	// Конфиг, который видел шаг add-skip-cert: по нему -warn-sni-mismatch
+	// сопоставляет прокси с их итогами
+	fixInput := res.Content
+	for _, name := range opts.transforms() {
+		if name != addSkipCert {
+			var err error
+			if res.Content, err = runTransformer(res.Content, name, opts, &res); err != nil {
+				return res, err
+			}
+			continue
+		}
+		fixInput = res.Content
+		step, err := fixSkipCert(res.Content, opts)
+		if err != nil {
+			return res, err
+		}
+		// Итоги преобразований до этого шага сохраняются
+		step.Transformed = res.Transformed
+		step.Records = append(res.Records, step.Records...)
+		step.Changes = append(res.Changes, step.Changes...)
+		res = step
+	}
+
+	// -emit-empty-section: конфиг без секции прокси получает пустой список
+	if opts.EmitEmptySection && !hasProxySection(content, opts.proxiesKeys()) {
+		res.Content = emptySection(res.Content, opts.ProxiesKey)
+		res.EmptySection = true
+	}
+
+	res.Warnings = append(res.Warnings, checkConfig(content, opts)...)
+	if opts.reconcilesSNI() {
+		res.Warnings = append(res.Warnings, sniConflicts(content, opts)...)
+	}
+	if opts.WarnSNIMismatch {
+		res.Warnings = append(res.Warnings, sniWarnings(fixInput, res.Proxies, opts)...)
+	}
+	if opts.DedupBy != "" {
+		res.Warnings = dedupWarnings(res.Warnings, dups)
+		res.Duplicates = dups
+		for _, dup := range dups {
+			res.Proxies = append(res.Proxies, proxyStatus{
+				Name:   dup.Name,
+				Type:   dup.Type,
+				Server: dup.Server,
+				Line:   dup.Line,
+				Status: statusDuplicate,
+			})
+		}
+	}
+	if opts.DedupKeys != "" {
+		// Повторы полей уже убраны и перечислены в KeyDups
+		out := res.Warnings[:0]
+		for _, w := range res.Warnings {
+			if w.Kind != warnDuplicateKey {
+				out = append(out, w)
+			}
+		}
+		res.Warnings = out
+		res.KeyDups = keyDups
+	}
+	res.Bools = bools
+	sort.SliceStable(res.Warnings, func(i, j int) bool {
+		return res.Warnings[i].Line < res.Warnings[j].Line
+	})
+	if opts.FailUnknownType {
+		return res, unsupportedTypes(res.Warnings)
+	}
+	return res, nil
+}
+
+// fixSkipCert добавляет поле одним из способов: текстовый сохраняет
+// форматирование файла как есть, структурный нужен операциям над полями.
+// С -field-remove-if шаг вместо этого удаляет поле по условию.
+func fixSkipCert(content string, opts *options) (fixResult, error) {
+	if opts.RemoveIf != nil {
+		return fixRemoveIf(content, opts)
+	}
+	if len(opts.KeepFields) > 0 || opts.nestedPlacement() || flowDocument(content) || flowSection(content, opts.proxiesKeys()) ||
+		aliasedSection(content, opts.proxiesKeys()) {
+		return fixConfigStructural(content, opts)
+	}
+	return fixConfig(content, opts), nil
+}
+
+// fixConfig добавляет skip-cert-verify: true ко всем прокси в конфиге
+func fixConfig(content string, opts *options) fixResult {
+	res := fixResult{Content: content}
+
+	// Компактные записи { ... }, многострочные или и те и другие
+	var entries []proxyEntry
+	entries, res.Format = proxyEntries(content, opts.proxiesKeys())
+	res.Found = len(entries)
+
+	// Обрезанная подписка: последняя запись "- {" без "}" не находится
+	// поиском выше и пропадала бы молча
+	if start, line := unterminatedEntry(content); line > 0 && inProxySection(content, start, opts.proxiesKeys()) {
+		name, _ := fieldValue(content[start:], "name")
+		res.Warnings = append(res.Warnings, warning{
+			Kind:    warnUnterminated,
+			Proxy:   name,
+			Line:    line,
+			Message: "запись прокси '{' не закрыта до конца файла (файл обрезан?) и пропущена",
+		})
+	}
+
+	// Якоря, уже задающие skip-cert-verify: прокси, которые их подключают,
+	// поле не дублируем, а с -use-anchor подключаем якорь вместо поля
+	var anchors []string
+	if strings.Contains(content, "&") {
+		var mergeable string
+		anchors, mergeable = fieldAnchorNames(content, defaultPlacement, formatValue(typedValue(opts.Value, opts.FieldType)))
+		if opts.UseAnchor {
+			res.Anchor = mergeable
+		}
+	}
+
+	// Значения из комментариев # x509:value=... у отдельных прокси
+	overrides := valueOverrides(content, opts.proxiesKeys())
+	// Прокси, у которых проверка уже настроена во вложенном блоке
+	nested := nestedVerifyLines(content, opts.proxiesKeys())
+
+	eol := lineEnding(content)
+	var sb strings.Builder
+	prev := 0
+	for _, entry := range entries {
+		text := content[entry.Start:entry.End]
+		// Поля ищутся без комментариев, записывается исходный текст
+		fields := blankComments(text)
+
+		// Проверяем, что это прокси (имеет минимальный набор полей)
+		if !isProxyEntry(fields, entry.Compact) {
+			if !entry.Compact || inProxySection(content, entry.Start, opts.proxiesKeys()) {
+				res.Warnings = append(res.Warnings, warning{
+					Kind:    warnParseSkip,
+					Line:    entry.Line,
+					Message: "запись не похожа на прокси (нет name, server или port) и пропущена",
+				})
+			}
+			continue
+		}
+
+		name, _ := fieldValue(fields, "name")
+		proxyType, hasType := fieldValue(fields, "type")
+		server, _ := fieldValue(fields, "server")
+		status := proxyStatus{Name: name, Type: proxyType, Server: server, Line: entry.Line}
+		if hasType && !isKnownProxyType(proxyType) {
+			res.Warnings = append(res.Warnings, warning{
+				Kind:    warnUnknownType,
+				Proxy:   name,
+				Line:    entry.Line,
+				Value:   proxyType,
+				Message: fmt.Sprintf("неизвестный тип прокси '%s'", proxyType),
+			})
+		}
+
+		// -include-name / -exclude-name: остальные прокси не изменяются
+		if !opts.nameSelected(name) {
+			res.Filtered++
+			status.Status = statusFiltered
+			res.Proxies = append(res.Proxies, status)
+			continue
+		}
+		// -include-server / -exclude-server
+		if !opts.serverSelected(server) {
+			res.ServerOut++
+			status.Status = statusServerOut
+			res.Proxies = append(res.Proxies, status)
+			continue
+		}
+		// -only-type-tls: поле добавляется только прокси с TLS
+		if opts.OnlyTypeTLS {
+			tlsValue, hasTLS := fieldValue(fields, "tls")
+			var tls bool
+			if tls, status.TLS = usesTLS(proxyType, tlsValue, hasTLS); !tls {
+				res.NoTLS++
+				status.Status = statusNoTLS
+				res.Proxies = append(res.Proxies, status)
+				continue
+			}
+		}
+		if !opts.hasRequired(func(key string) bool { return hasField(fields, entryKey(fields, key, opts.IgnoreCaseKeys)) }) {
+			res.NoRequired++
+			status.Status = statusNoField
+			res.Proxies = append(res.Proxies, status)
+			continue
+		}
+		if hasType && !opts.flavorSupports(proxyType) {
+			res.Warnings = append(res.Warnings, flavorWarning(opts, name, entry.Line, proxyType))
+			res.Skipped++
+			status.Status = statusSkipped
+			res.Proxies = append(res.Proxies, status)
+			continue
+		}
+
+		// Проверяем наличие поля (ключ из -placement, здесь без вложенности)
+		// С -ignore-case-keys поле ищется и в другом написании (Skip-Cert-Verify),
+		// а добавляется в каноническом
+		key := opts.placement(proxyType)
+		present := entryKey(fields, key, opts.IgnoreCaseKeys)
+		mapped, inMap := opts.ValueMap.lookup(name, server)
+		status.Nested = nested[entry.Line]
+		if hasField(fields, present) || (key == defaultPlacement && mergesAnyAnchor(fields, anchors)) || status.Nested != "" {
+			if existing, ok := fieldValue(fields, present); ok && inMap {
+				if w, conflict := mapConflict(name, entry.Line, existing, mapped); conflict {
+					res.Warnings = append(res.Warnings, w)
+				}
+			}
+			res.AlreadyHas++
+			status.Status = statusAlready
+			res.Proxies = append(res.Proxies, status)
+			continue
+		}
+
+		// Серверы из -deny-servers всегда сохраняют проверку сертификата
+		if opts.DenyServers.matches(server) {
+			res.Protected++
+			status.Status = statusProtected
+			res.Proxies = append(res.Proxies, status)
+			continue
+		}
+
+		// С -error-log поле получают только серверы с ошибками сертификата
+		if opts.ErrorHosts != nil && !opts.ErrorHosts.matches(server) {
+			res.Skipped++
+			status.Status = statusSkipped
+			res.Proxies = append(res.Proxies, status)
+			continue
+		}
+
+		// Комментарий у прокси важнее -map, -map — важнее -value
+		value := opts.Value
+		if marker, ok := overrides[entry.Line]; ok {
+			value = marker
+			status.FromMarker = true
+		} else if inMap {
+			value = mapped
+			status.FromMap = true
+		}
+		entryInsertion := key + ": " + formatValue(typedValue(value, opts.FieldType))
+		if res.Anchor != "" && key == defaultPlacement && !status.FromMarker && !status.FromMap {
+			entryInsertion = "<<: *" + res.Anchor
+		}
+		status.Value = insertionValue(entryInsertion)
+
+		insertion := entryInsertion
+		if c := opts.fieldComment(); c != "" && !entry.Compact {
+			insertion += " " + c
+		}
+		fixed := insertField(text, entry.Compact, eol, blockIndent(content, entry), insertion)
+		if entry.Compact && opts.Wrap > 0 {
+			fixed, _ = wrapEntry(content, entry, fixed, eol, opts.Wrap)
+		}
+		if entry.Compact {
+			fixed = commentCompact(content, entry, fixed, opts.fieldComment())
+		}
+		sb.WriteString(content[prev:entry.Start])
+		sb.WriteString(fixed)
+		prev = entry.End
+		res.Modified++
+		res.Records = append(res.Records, recordChange{Name: name, Line: entry.Line, Before: text, After: fixed})
+		if opts.Explain {
+			res.Changes = append(res.Changes, insertionChange(name, entry.Line, entryInsertion))
+		}
+		status.Status = statusModified
+		res.Proxies = append(res.Proxies, status)
+	}
+	sb.WriteString(content[prev:])
+	res.Warnings = append(res.Warnings, unmatchedProxies(content, entries, opts.proxiesKeys())...)
+
+	if res.Modified > 0 {
+		res.Content = sb.String()
+	}
+	if res.total() == 0 {
+		res.Format = ""
+	}
+	return res
+}
+
+// unmatchedProxies возвращает предупреждения malformed о прокси, которые
+// YAML разбирает (и клиент загрузит), а в найденных записях их нет:
+// запись в необычном формате пропадала бы из обработки молча
+func unmatchedProxies(content string, entries []proxyEntry, keys []string) []warning {
+	doc, err := loadDocument(content)
+	if err != nil {
+		return nil
+	}
+	seq := proxiesNode(doc, keys)
+	if seq == nil {
+		return nil
+	}
+	lineStarts := lineOffsets(content)
+	var warnings []warning
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		pos := nodeOffset(content, lineStarts, item)
+		found := false
+		for _, entry := range entries {
+			if pos >= entry.Start && pos < entry.End {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		warnings = append(warnings, warning{
+			Kind:    warnMalformed,
+			Proxy:   scalarValue(item, "name"),
+			Line:    item.Line,
+			Message: fmt.Sprintf("прокси около строки %d записан в необычном формате и пропущен, хотя YAML его читает: проверьте запись", item.Line),
+		})
+	}
+	return warnings
+}
+
+// isProxyEntry проверяет, что запись похожа на прокси
+func isProxyEntry(text string, compact bool) bool {
+	if !compact {
+		return hasField(text, "name")
+	}
+	return hasField(text, "name") && hasField(text, "server") && hasField(text, "port")
+}
+
+// insertField возвращает запись с добавленной парой "ключ: значение";
+// indent — отступ полей многострочной записи (blockIndent)
+func insertField(text string, compact bool, eol, indent, keyValue string) string {
+	if !compact {
+		// Для многострочного формата добавляем новую строку
+		// после первой строки записи
+		firstLine, rest, found := strings.Cut(text, "\n")
+		result := strings.TrimSuffix(firstLine, "\r") + eol + indent + keyValue
+		if found {
+			result += eol + rest
+		}
+		return result
+	}
+
+	// "-{ ... }" без пробела YAML читает как строку, а не элемент списка:
+	// дефис оставляем один и отделяем пробелом
+	cleaned := text
+	if rest, ok := strings.CutPrefix(cleaned, "-{"); ok {
+		cleaned = "- {" + rest
+	}
+
+	// Поле ставится сразу после последнего значения перед закрывающей
+	// скобкой: пробелы, переводы строк и комментарий перед "}" остаются
+	// на месте, а запятая в конце ", }" служит разделителем
+	end := strings.LastIndexByte(cleaned, '}')
+	at := lastValueEnd(cleaned[:end])
+	sep := ", "
+	if before := strings.TrimRight(cleaned[:at], " \t"); strings.HasSuffix(before, ",") || strings.HasSuffix(before, "{") {
+		sep = " "
+		at = len(before)
+	}
+	return cleaned[:at] + sep + keyValue + cleaned[at:]
+}
+
+// lastValueEnd возвращает позицию в тексте записи без "}" сразу после
+// последнего значения: без пробелов, переводов строк и комментария в конце
+func lastValueEnd(text string) int {
+	for {
+		trimmed := strings.TrimRight(text, " \t\r\n")
+		lineStart := strings.LastIndexByte(trimmed, '\n') + 1
+		_, code := scanQuotes(trimmed[lineStart:], 0)
+		code = strings.TrimRight(code, " \t")
+		if code != "" || lineStart == 0 {
+			return lineStart + len(code)
+		}
+		// Строка из одного комментария: значение выше
+		text = trimmed[:lineStart]
+	}
+}
+
+// blockIndent возвращает отступ полей многострочной записи entry: как у
+// первой строки с полем после строки с "-". Отступ у каждой записи свой:
+// в отредактированных вручную конфигах соседние прокси бывают сдвинуты
+// по-разному. Если других строк нет, отступ — столбец ключа после "- ".
+func blockIndent(content string, entry proxyEntry) string {
+	text := content[entry.Start:entry.End]
+	if _, rest, found := strings.Cut(text, "\n"); found {
+		for _, line := range strings.Split(rest, "\n") {
+			body := strings.TrimRight(line, "\r")
+			trimmed := strings.TrimLeft(body, " \t")
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				return body[:len(body)-len(trimmed)]
+			}
+		}
+	}
+	lineStart := strings.LastIndexByte(content[:entry.Start], '\n') + 1
+	afterDash := strings.TrimLeft(strings.TrimPrefix(text, "-"), " ")
+	width := entry.Start - lineStart + len(text) - len(afterDash)
+	return strings.Repeat(" ", width)
+}
+
+// lineEnding определяет перевод строки, принятый в файле
+func lineEnding(content string) string {
+	if strings.Contains(content, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// setLineEnding приводит переводы строк к -eol: lf или crlf;
+// preserve оставляет их как во входном файле
+func setLineEnding(content, eol string) string {
+	switch eol {
+	case "lf":
+		return strings.ReplaceAll(content, "\r\n", "\n")
+	case "crlf":
+		return strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+	}
+	return content
+}
+
+// proxyEntries находит записи прокси в тексте и возвращает их с форматом:
+// compact, multiline или mixed — компактные записи вперемешку
+// с многострочными (например, после неудачного слияния конфигов).
+// Многострочная запись, в которой есть компактная, не добавляется:
+// прокси — это компактная запись. Так находятся и многострочные прокси
+// в конфиге, где компактны только записи групп.
+func proxyEntries(content string, keys []string) ([]proxyEntry, string) {
+	entries := findCompactEntries(content)
+	blocks := findBlockEntries(content, keys)
+	if len(entries) == 0 {
+		return blocks, "multiline"
+	}
+	format := "compact"
+	compact := len(entries)
+	for _, block := range blocks {
+		// "- {" без пары — обрезанная компактная запись (unterminatedEntry)
+		overlaps := strings.HasPrefix(strings.TrimLeft(content[block.Start+1:block.End], " \t\r\n"), "{")
+		for _, entry := range entries {
+			if overlaps || entry.Start < block.End && block.Start < entry.End {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			entries = append(entries, block)
+		}
+	}
+	if len(entries) > compact {
+		// Компактные записи только вне секции прокси (например, группы):
+		// прокси — многострочные
+		format = "multiline"
+		for _, entry := range entries[:compact] {
+			if inProxySection(content, entry.Start, keys) {
+				format = "mixed"
+				break
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Start < entries[j].Start })
+	return entries, format
+}
+
+// findCompactEntries находит записи прокси в компактном формате
+func findCompactEntries(content string) []proxyEntry {
+	var entries []proxyEntry
+	last := 0
+	for _, m := range compactStarts(content) {
+		if m[0] < last {
+			continue
+		}
+		// Закомментированные записи (# - { ... }) не трогаем
+		lineStart := strings.LastIndexByte(content[:m[0]], '\n') + 1
+		if strings.Contains(content[lineStart:m[0]], "#") {
+			continue
+		}
+		_, end := flowScan(content[m[1]-1:])
+		if end < 0 {
+			continue // скобка не закрыта: unterminatedEntry
+		}
+		last = m[1] - 1 + end
+		entries = append(entries, proxyEntry{
+			Start:   m[0],
+			End:     last,
+			Line:    strings.Count(content[:m[0]], "\n") + 1,
+			Compact: true,
+		})
+	}
+	return entries
+}
+
+// unterminatedEntry находит последнюю компактную запись "- {", скобка
+// которой не закрыта до конца файла, и возвращает ее начало и номер строки
+// (0 — такой записи нет). Скобки в кавычках и комментариях не считаются.
+func unterminatedEntry(content string) (int, int) {
+	matches := compactStarts(content)
+	for k := len(matches) - 1; k >= 0; k-- {
+		start := matches[k][0]
+		lineStart := strings.LastIndexByte(content[:start], '\n') + 1
+		if strings.Contains(content[lineStart:start], "#") {
+			continue
+		}
+		if flowDepth(content[matches[k][1]-1:]) == 0 {
+			return 0, 0
+		}
+		return start, strings.Count(content[:start], "\n") + 1
+	}
+	return 0, 0
+}
+
+// flowDepth возвращает глубину незакрытых скобок { } и [ ] в конце текста
+func flowDepth(text string) int {
+	depth, _ := flowScan(text)
+	return depth
+}
+
+// flowScan проходит текст со скобками { } и [ ] и возвращает глубину
+// незакрытых скобок в конце и позицию сразу после скобки, которая закрыла
+// все открытые (-1 — такой нет). Скобки в кавычках и комментариях не считаются.
+func flowScan(text string) (int, int) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			if end := strings.IndexByte(text[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(text)
+			}
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			if depth--; depth == 0 {
+				return 0, i + 1
+			}
+		}
+	}
+	return depth, -1
+}
+
+// findBlockEntries находит элементы списка в секции прокси (ключи keys)
+// многострочного формата. Запись занимает строки от "-" до следующего
+// элемента того же уровня или до конца секции. В списке прокси без ключа
+// (bareList) секцией считается весь файл.
+func findBlockEntries(content string, keys []string) []proxyEntry {
+	var entries []proxyEntry
+	inSection := bareList(content)
+	itemIndent := -1
+	current := -1 // индекс текущей записи в entries
+
+	offset := 0
+	for lineNo, line := range strings.SplitAfter(content, "\n") {
+		lineStart := offset
+		offset += len(line)
+		body := strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimSpace(body)
+		indent := len(body) - len(strings.TrimLeft(body, " \t"))
+
+		// Начало секции прокси
+		if indent == 0 && isSectionHeader(trimmed, keys) {
+			inSection = true
+			itemIndent = -1
+			current = -1
+			continue
+		}
+		if !inSection || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Ключ верхнего уровня завершает секцию proxies
+		if indent == 0 && !strings.HasPrefix(trimmed, "-") {
+			inSection = false
+			current = -1
+			continue
+		}
+
+		// Новый элемент списка
+		if strings.HasPrefix(trimmed, "-") && (itemIndent == -1 || indent == itemIndent) {
+			itemIndent = indent
+			entries = append(entries, proxyEntry{
+				Start: lineStart + indent,
+				End:   lineStart + len(body),
+				Line:  lineNo + 1,
+			})
+			current = len(entries) - 1
+			continue
+		}
+
+		// Продолжение текущего элемента
+		if current >= 0 && indent > itemIndent {
+			entries[current].End = lineStart + len(body)
+		}
+	}
+	return entries
+}
+
+// hasProxySection проверяет, есть ли в документе ключ верхнего уровня
+// со списком прокси (даже пустым) или сам документ — список прокси
+func hasProxySection(content string, keys []string) bool {
+	if bareList(content) {
+		return true
+	}
+	for _, line := range strings.Split(content, "\n") {
+		for _, key := range keys {
+			if strings.HasPrefix(line, key+":") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flowSection проверяет, что список прокси записан в потоковом стиле
+// proxies: [{...}, {...}]. Ни компактный, ни многострочный поиск такие
+// записи не находят, поэтому список обрабатывается через разбор YAML;
+// потоковый стиль при записи сохраняется.
+func flowSection(content string, keys []string) bool {
+	if !strings.Contains(content, "[") {
+		return false
+	}
+	doc, err := loadDocument(content)
+	if err != nil {
+		return false
+	}
+	seq := proxiesNode(doc, keys)
+	return seq != nil && seq.Style&yaml.FlowStyle != 0 && len(seq.Content) > 0
+}
+
+// spliceFlowLine переносит из результата разбора YAML только строку со
+// списком прокси, если в исходном тексте весь список записан на одной
+// строке с ключом (proxies: [ {...}, {...} ]). Остальные строки файла
+// остаются байт в байт. Иначе возвращается result целиком.
+func spliceFlowLine(content, result string, keys []string) string {
+	find := func(text string) (int, []string) {
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			for _, key := range keys {
+				rest, ok := strings.CutPrefix(line, key+":")
+				if !ok || !strings.HasPrefix(strings.TrimSpace(rest), "[") {
+					continue
+				}
+				var seq []interface{}
+				if yaml.Unmarshal([]byte(strings.TrimSpace(rest)), &seq) == nil {
+					return i, lines
+				}
+			}
+		}
+		return -1, lines
+	}
+	i, lines := find(content)
+	j, out := find(result)
+	if i < 0 || j < 0 {
+		return result
+	}
+	cr := ""
+	if strings.HasSuffix(lines[i], "\r") {
+		cr = "\r"
+	}
+	lines[i] = strings.TrimSuffix(out[j], "\r") + cr
+	return strings.Join(lines, "\n")
+}
+
+// emptySection добавляет в конец документа пустой список прокси key: []
+func emptySection(content, key string) string {
+	eol := lineEnding(content)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += eol
+	}
+	return content + key + ": []" + eol
+}
+
+// isSectionHeader проверяет, что строка — заголовок секции с одним из
+// ключей keys (допускается комментарий после двоеточия)
+func isSectionHeader(trimmed string, keys []string) bool {
+	for _, key := range keys {
+		if !strings.HasPrefix(trimmed, key+":") {
+			continue
+		}
+		rest := strings.TrimSpace(trimmed[len(key)+1:])
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			return true
+		}
+	}
+	return false
+}
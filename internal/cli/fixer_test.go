@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCompactStartInValue(t *testing.T) {
+	data, err := os.ReadFile("testdata/brace_in_value.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := fixConfig(string(data), testOptions(t))
+	if res.Format != "multiline" || res.Found != 2 || res.Modified != 2 {
+		t.Errorf("format %q, найдено %d, добавлено %d; want multiline, 2, 2", res.Format, res.Found, res.Modified)
+	}
+	for _, w := range res.Warnings {
+		t.Errorf("лишнее предупреждение: строка %d: %s", w.Line, w.Message)
+	}
+}
+
+func TestCompactStarts(t *testing.T) {
+	cases := []struct {
+		content string
+		want    []string // текст от дефиса до скобки
+	}{
+		{"proxies:\n  - { name: a }\n", []string{"- {"}},
+		{"proxies:\n- {name: a}\n", []string{"- {"}},
+		{"proxies:\n  -\n    { name: a }\n", []string{"-\n    {"}},
+		{"groups:\n  - - { name: a }\n", []string{"- {"}},
+		{"    password: abc-{def}\n", nil},
+		{"    password: \"x - {y}\"\n", nil},
+		{"  - name: a-{b}\n", nil},
+		{"proxies: [a, - {name: b}]\n", []string{"- {"}},
+	}
+	for _, c := range cases {
+		var got []string
+		for _, m := range compactStarts(c.content) {
+			got = append(got, c.content[m[0]:m[1]])
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("compactStarts(%q) = %q, want %q", c.content, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("compactStarts(%q) = %q, want %q", c.content, got, c.want)
+				break
+			}
+		}
+	}
+}
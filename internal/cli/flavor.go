@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Клиенты -flavor
+const (
+	flavorMeta    = "meta"    // Mihomo (Clash.Meta), по умолчанию
+	flavorPremium = "premium" // Clash Premium
+)
+
+// flavorProfile — что принимает клиент. Типы, которых нет в Clash Premium,
+// отмечены в proxyTypes, поля перечислены здесь: остальное у клиентов
+// совпадает.
+type flavorProfile struct {
+	Title string
+	// Типы прокси, которые клиент не знает: поле им не добавляется
+	NoTypes map[string]bool
+	// Поля прокси, которых клиент не знает: -field с ними не принимается
+	NoFields map[string]bool
+	// Парсер YAML 1.1: yes/no/on/off читаются как логические. Mihomo
+	// читает YAML 1.2, и для него они — строки.
+	YAML11 bool
+}
+
+// flavors — таблица совместимости клиентов
+var flavors = map[string]flavorProfile{
+	flavorMeta: {Title: "Mihomo (Clash.Meta)"},
+	flavorPremium: {
+		Title:   "Clash Premium",
+		NoTypes: metaOnlyTypes(),
+		NoFields: map[string]bool{
+			"client-fingerprint": true, "fingerprint": true, "reality-opts": true,
+			"ech-opts": true, "smux": true, "tfo": true, "mptcp": true,
+			"ip-version": true, "udp-over-tcp": true,
+		},
+		YAML11: true,
+	},
+}
+
+// flavorNames возвращает имена клиентов -flavor по алфавиту
+func flavorNames() []string {
+	names := make([]string, 0, len(flavors))
+	for name := range flavors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// flavor возвращает профиль клиента -flavor
+func (o *options) flavor() flavorProfile {
+	return flavors[o.Flavor]
+}
+
+// flavorSupports проверяет, что клиент -flavor знает тип прокси.
+// Прокси без типа не пропускаются.
+func (o *options) flavorSupports(proxyType string) bool {
+	return !o.flavor().NoTypes[proxyType]
+}
+
+// flavorWarning — предупреждение о прокси, тип которого клиент не знает
+func flavorWarning(opts *options, name string, line int, proxyType string) warning {
+	return warning{
+		Kind:    warnFlavor,
+		Proxy:   name,
+		Line:    line,
+		Value:   proxyType,
+		Message: fmt.Sprintf("тип прокси '%s' не поддерживается клиентом %s (-flavor %s), поле не добавлено", proxyType, opts.flavor().Title, opts.Flavor),
+	}
+}
+
+// checkFlavor проверяет -flavor и поля, которые будут добавлены, и
+// приводит значение к формату клиента: для Mihomo yes/no/on/off
+// в skip-cert-verify записываются как true/false, иначе он прочитает строку
+func checkFlavor(opts *options) error {
+	profile, ok := flavors[opts.Flavor]
+	if !ok {
+		return fmt.Errorf("неизвестный клиент -flavor %q (допустимо: %s)", opts.Flavor, strings.Join(flavorNames(), ", "))
+	}
+	paths := []string{opts.Field}
+	for _, path := range opts.Placements {
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		if key := strings.Split(path, ".")[0]; profile.NoFields[key] {
+			return fmt.Errorf("поле %s не поддерживается клиентом %s (-flavor %s)", key, profile.Title, opts.Flavor)
+		}
+	}
+	if !profile.YAML11 && opts.Field == defaultPlacement && opts.FieldType == fieldAuto {
+		switch strings.ToLower(opts.Value) {
+		case "yes", "on", "y":
+			opts.Value = "true"
+		case "no", "off", "n":
+			opts.Value = "false"
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// csvRequired — колонки, без которых строка CSV не становится прокси
+var csvRequired = []string{"name", "type", "server", "port"}
+
+// csvProxies читает CSV (-from-csv) и возвращает секцию прокси в компактном
+// формате. Заголовок задает колонки: name, type, server, port обязательны,
+// остальные (password, uuid, sni, ...) становятся полями прокси. Пустые
+// ячейки пропускаются. Неверные строки не прерывают разбор, а попадают
+// в предупреждения.
+func csvProxies(r io.Reader, key string) (string, int, []warning, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return "", 0, nil, fmt.Errorf("пустой файл")
+	}
+	if err != nil {
+		return "", 0, nil, err
+	}
+	columns := map[string]bool{}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if header[i] == "" || columns[header[i]] {
+			return "", 0, nil, fmt.Errorf("колонка %d: пустое или повторяющееся имя в заголовке", i+1)
+		}
+		columns[header[i]] = true
+	}
+	for _, column := range csvRequired {
+		if !columns[column] {
+			return "", 0, nil, fmt.Errorf("в заголовке нет колонки %s (обязательны: %s)",
+				column, strings.Join(csvRequired, ", "))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(key + ":\n")
+	var warnings []warning
+	rows := 0
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			return "", rows, warnings, err
+		}
+		if msg := checkCSVRow(header, row); msg != "" {
+			warnings = append(warnings, warning{Kind: warnParseSkip, Line: line, Message: msg})
+			continue
+		}
+
+		var fields []string
+		for i, column := range header {
+			if value := strings.TrimSpace(row[i]); value != "" {
+				fields = append(fields, column+": "+csvValue(value))
+			}
+		}
+		sb.WriteString("  - { " + strings.Join(fields, ", ") + " }\n")
+		rows++
+	}
+	return sb.String(), rows, warnings, nil
+}
+
+// checkCSVRow возвращает причину, по которой строка не становится прокси
+func checkCSVRow(header, row []string) string {
+	if len(row) != len(header) {
+		return fmt.Sprintf("строка CSV пропущена: колонок %d, а в заголовке %d", len(row), len(header))
+	}
+	values := map[string]string{}
+	for i, column := range header {
+		values[column] = strings.TrimSpace(row[i])
+	}
+	for _, column := range csvRequired {
+		if values[column] == "" {
+			return fmt.Sprintf("строка CSV пропущена: пустое поле %s", column)
+		}
+	}
+	if port, err := strconv.Atoi(values["port"]); err != nil || port < 1 || port > 65535 {
+		return fmt.Sprintf("строка CSV пропущена: неверный порт %q", values["port"])
+	}
+	return ""
+}
+
+// csvValue возвращает значение ячейки для YAML: числа и true/false — как
+// есть, остальное — строкой, в кавычках, если они нужны
+func csvValue(value string) string {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return value
+	}
+	if value == "true" || value == "false" {
+		return value
+	}
+	return formatValue(value, fieldString)
+}
+
+// runFromCSV строит конфиг из CSV, добавляет в нем поле ко всем прокси
+// и записывает результат; возвращает код выхода
+func runFromCSV(outputFile string, opts *options) int {
+	if !opts.DryRun {
+		if err := checkWritable(outputFile, opts.Mkdir); err != nil {
+			fmt.Fprintf(errConsole, "❌ Результат %s нельзя записать: %v\n", outputFile, err)
+			return 1
+		}
+	}
+	sayf("📖 Чтение CSV: %s\n", opts.FromCSV)
+	f, err := os.Open(opts.FromCSV)
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ Ошибка чтения CSV: %v\n", err)
+		return 2
+	}
+	content, rows, csvWarnings, err := csvProxies(f, opts.ProxiesKey)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ %s: %v\n", opts.FromCSV, err)
+		return 2
+	}
+
+	res, err := processContent(content, opts)
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ %v\n", err)
+		return 1
+	}
+	if opts.TrimTrailing {
+		res.Content = trimTrailingWhitespace(res.Content)
+	}
+	res.Content = setLineEnding(res.Content, opts.EOL)
+	// Номера строк в предупреждениях — строки CSV; у предупреждений
+	// по построенному YAML их нет, прокси указан по имени
+	for i := range res.Warnings {
+		res.Warnings[i].Line = 0
+	}
+	res.Warnings = append(csvWarnings, res.Warnings...)
+
+	say()
+	sayf("📋 Строк CSV преобразовано в прокси: %d\n", rows)
+	sayf("⏭️  Строк CSV пропущено: %d\n", len(csvWarnings))
+	for _, w := range res.Warnings {
+		sayf("⚠️  %s\n", w.text())
+	}
+	sayf("✅ Обработано прокси: %d\n", res.Modified)
+
+	summary := jsonSummary{
+		Input:      opts.FromCSV,
+		Output:     outputFile,
+		Format:     res.Format,
+		Flavor:     opts.Flavor,
+		Modified:   res.Modified,
+		AlreadyHas: res.AlreadyHas,
+		Total:      res.total(),
+		Protected:  res.Protected,
+		Skipped:    res.Skipped,
+		Filtered:   res.Filtered,
+		NoRequired: res.NoRequired,
+		ServerOut:  res.ServerOut,
+		NoTLS:      res.NoTLS,
+		Warnings:   res.Warnings,
+		Changes:    opts.redactChanges(res.Changes),
+	}
+	code := 0
+	switch {
+	case opts.Strict && len(res.Warnings) > 0:
+		sayf("❌ Режим -strict: найдено предупреждений: %d, результат не сохранен\n", len(res.Warnings))
+		code = 1
+	case opts.DryRun:
+		say()
+		say("🔍 РЕЗУЛЬТАТ (пробный запуск, файл не записан):")
+		say("══════════════════════════════════════════════")
+		sayf("%s", res.Content)
+		say("══════════════════════════════════════════════")
+		if err := writePreview(opts, opts.FromCSV, res.Content, textEncoding{}); err != nil {
+			fmt.Fprintf(errConsole, "❌ Ошибка записи -dry-run-out: %v\n", err)
+			return 1
+		}
+	case !confirmOverwrite(opts, outputFile):
+	default:
+		if err := writeResult(opts, outputFile, []byte(res.Content)); err != nil {
+			fmt.Fprintf(errConsole, "❌ Ошибка сохранения файла: %v\n", err)
+			return 1
+		}
+		summary.Written = true
+		sayf("💾 Результат: %s\n", outputFile)
+	}
+	if code == 0 && !summaryWebhook(opts, summary, "") {
+		code = 1
+	}
+	if opts.JSON {
+		printJSONSummary(summary, opts)
+	}
+	if code == 0 && !checkModifiedLimits(opts, res.Modified, res.total()) {
+		code = 1
+	}
+	return code
+}
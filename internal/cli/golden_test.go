@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// updateGolden перезаписывает эталоны: go test -run Golden -update
+var updateGolden = flag.Bool("update", false, "перезаписать эталоны testdata/*.fixed")
+
+// goldenCase — файл из testdata, параметры запуска и эталон результата
+type goldenCase struct {
+	name     string
+	file     string
+	args     []string
+	golden   string   // эталон результата, по умолчанию file + ".fixed"
+	warnings []string // виды предупреждений по порядку
+}
+
+// runGolden обрабатывает каждый файл как processFile в пакетном режиме
+// (для YAML это processDocument) и сравнивает результат с эталоном
+func runGolden(t *testing.T, cases []goldenCase) {
+	t.Helper()
+	for _, c := range cases {
+		data, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := testOptions(t, c.args...)
+		res, err := processFile(string(data), c.file, opts)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		golden := c.golden
+		if golden == "" {
+			golden = c.file + ".fixed"
+		}
+		if *updateGolden {
+			if err := os.WriteFile(golden, []byte(res.Content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Content != string(want) {
+			t.Errorf("%s: результат отличается от %s:\n--- получено\n%s\n--- эталон\n%s", c.name, golden, res.Content, want)
+		}
+		var kinds []string
+		for _, w := range res.Warnings {
+			kinds = append(kinds, w.Kind)
+		}
+		if !reflect.DeepEqual(kinds, c.warnings) {
+			t.Errorf("%s: предупреждения %q, want %q", c.name, kinds, c.warnings)
+		}
+	}
+}
+
+// TestTabsGolden — поля с табуляцией после двоеточия
+func TestTabsGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		{name: "tabs", file: "testdata/tabs.yaml"},
+		// Имя после табуляций читается целиком: фильтр выбирает одну запись
+		{name: "tabs -include-name", file: "testdata/tabs.yaml", args: []string{"-include-name", "^Server2$"},
+			golden: "testdata/tabs.yaml.include.fixed"},
+	})
+}
+
+// TestUnknownTypeGolden — опечатка в типе прокси
+func TestUnknownTypeGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		// Поле добавляется и прокси с неизвестным типом, но с предупреждением
+		{name: "unknown type", file: "testdata/unknown_type.yaml", warnings: []string{"unknown-type"}},
+	})
+}
+
+// TestValueMarkersGolden — значение из -value и из отметок # x509:value
+func TestValueMarkersGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		// Отметка сильнее -value: Server2 и Server3 получают false
+		{name: "value markers", file: "testdata/value_markers.yaml"},
+	})
+}
+
+// TestJSONConfigGolden — конфиг целиком в JSON записывается обратно в JSON
+func TestJSONConfigGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		// Однострочный JSON остается одной строкой, поле есть у JSON-A
+		{name: "json", file: "testdata/json_config.yaml"},
+	})
+}
+
+// TestDashSpacingGolden — компактные записи без пробела после дефиса
+func TestDashSpacingGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		// "-{" без пробела — не YAML: записи исправляет текстовый движок
+		{name: "dash spacing", file: "testdata/dash_spacing.yaml", warnings: []string{"parse-error"}},
+	})
+}
+
+// TestIgnoreCaseKeysGolden — поле в написании форков
+func TestIgnoreCaseKeysGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		// Поле добавляется только прокси Missing
+		{name: "ignore case keys", file: "testdata/ignore_case_keys.yaml", args: []string{"-ignore-case-keys"}},
+	})
+}
+
+// TestMixedIndentGolden — поля записей с разным отступом
+func TestMixedIndentGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		{name: "mixed indent", file: "testdata/mixed_indent.yaml"},
+	})
+}
+
+// TestTruncatedGolden — незакрытая компактная запись в конце файла
+func TestTruncatedGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		// Целая запись получает поле, обрезанная остается как есть
+		{name: "truncated", file: "testdata/truncated.yaml", warnings: []string{"parse-error", "unterminated"}},
+	})
+}
+
+// TestFieldCommentGolden — комментарий у добавленного поля
+func TestFieldCommentGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		{name: "field comment", file: "testdata/field_comment.yaml", args: []string{"-field-comment", "added by err_x509"}},
+		// Повторный запуск по эталону ничего не меняет
+		{name: "field comment again", file: "testdata/field_comment.yaml.fixed", args: []string{"-field-comment", "added by err_x509"},
+			golden: "testdata/field_comment.yaml.fixed"},
+	})
+}
+
+// TestCompactInlineCommentsGolden — комментарии внутри компактных записей
+func TestCompactInlineCommentsGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		{name: "compact inline comments", file: "testdata/compact_inline_comments.yaml"},
+	})
+}
+
+// TestNormalizeBoolsGolden — логические значения в разных написаниях
+func TestNormalizeBoolsGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		{name: "normalize bools", file: "testdata/normalize_bools.yaml", args: []string{"-normalize-bools"}},
+	})
+}
+
+// TestMarkdownBlocksGolden — блоки yaml в markdown
+func TestMarkdownBlocksGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		{name: "markdown", file: "testdata/markdown_blocks.md", args: []string{"-markdown"}},
+	})
+}
+
+// TestReconcileSNIGolden — sni и servername дополняют друг друга
+func TestReconcileSNIGolden(t *testing.T) {
+	runGolden(t, []goldenCase{
+		// both-differ остается как есть с предупреждением о расхождении
+		{name: "reconcile sni", file: "testdata/reconcile_sni.yaml", args: []string{"-reconcile-sni", "both"},
+			warnings: []string{"sni-conflict"}},
+	})
+}
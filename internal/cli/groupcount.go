@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// groupCount — число прокси с одним значением поля (-group-count)
+type groupCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// groupCountResult — итог -group-count в режиме -json
+type groupCountResult struct {
+	Input  string       `json:"input"`
+	Key    string       `json:"key"`
+	Total  int          `json:"total"`
+	Groups []groupCount `json:"groups"`
+}
+
+// countBy группирует прокси по значению поля key (путь через точку)
+// и возвращает группы по убыванию числа прокси, при равенстве — по значению
+func countBy(proxies []map[string]interface{}, key string) []groupCount {
+	counts := map[string]int{}
+	for _, fields := range proxies {
+		counts[pathValue(fields, key)]++
+	}
+	groups := make([]groupCount, 0, len(counts))
+	for value, n := range counts {
+		groups = append(groups, groupCount{Value: value, Count: n})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Value < groups[j].Value
+	})
+	return groups
+}
+
+// runGroupCount выводит число прокси по значениям поля -group-count.
+// Файлы не записываются; код выхода 2 — ошибка чтения.
+func runGroupCount(inputFile string, opts *options) int {
+	proxies, err := loadProxyFields(inputFile, opts)
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ %s: %v\n", inputFile, err)
+		return 2
+	}
+	groups := countBy(proxies, opts.GroupCount)
+
+	if opts.JSON {
+		enc := jsonEncoder(os.Stdout, opts)
+		result := groupCountResult{Input: inputFile, Key: opts.GroupCount, Total: len(proxies), Groups: groups}
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(errConsole, "❌ Ошибка вывода JSON: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+
+	sayf("📊 ПРОКСИ ПО ПОЛЮ %s: %s\n", opts.GroupCount, inputFile)
+	say("══════════════════════════════════════════════")
+	if len(groups) == 0 {
+		say("Прокси не найдены")
+	} else {
+		tw := tabwriter.NewWriter(console, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for _, g := range groups {
+			value := g.Value
+			if value == compareNoField {
+				value = "(нет поля)"
+			}
+			fmt.Fprintf(tw, "%d\t  %s\t\n", g.Count, value)
+		}
+		tw.Flush()
+	}
+	say("══════════════════════════════════════════════")
+	sayf("   📄 Всего прокси: %d, значений: %d\n", len(proxies), len(groups))
+	return 0
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// splitCommand разбивает строку -post-hook на аргументы как sh, но без
+// запуска оболочки: пробелы разделяют аргументы, одинарные кавычки берут
+// текст как есть, в двойных и вне кавычек \ экранирует следующий символ.
+// Подстановки ($VAR, `cmd`, |, ;) не выполняются.
+func splitCommand(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("незакрытая одинарная кавычка")
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\$`+"`", s[i+1]) >= 0 {
+					i++
+				}
+				cur.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("незакрытая двойная кавычка")
+			}
+			inArg = true
+		case c == '\\' && i+1 < len(s):
+			i++
+			cur.WriteByte(s[i])
+			inArg = true
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("пустая команда")
+	}
+	return args, nil
+}
+
+// runPostHook запускает команду -post-hook после успешной записи результата.
+// Путь к результату, входной файл и число измененных прокси передаются
+// в переменных окружения ERR_X509_OUTPUT, ERR_X509_INPUT и ERR_X509_MODIFIED.
+// Вывод команды идет в stderr, чтобы не смешиваться с -json. Возвращает код
+// выхода команды (-1, если ее не удалось запустить) и ошибку.
+func runPostHook(opts *options, input, output string, modified int) (int, error) {
+	args, err := splitCommand(opts.PostHook)
+	if err != nil {
+		return -1, fmt.Errorf("-post-hook: %w", err)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"ERR_X509_INPUT="+input,
+		"ERR_X509_OUTPUT="+output,
+		"ERR_X509_MODIFIED="+strconv.Itoa(modified))
+	cmd.Stdout = errConsole
+	cmd.Stderr = errConsole
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0, nil
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode(), fmt.Errorf("-post-hook завершился с кодом %d", exitErr.ExitCode())
+	}
+	return -1, fmt.Errorf("-post-hook: %w", err)
+}
+
+// postHook запускает -post-hook, если он задан, и выводит код выхода.
+// Возвращает false, если команда не выполнилась, а задан -hook-strict.
+func postHook(opts *options, input, output string, modified int, summary *jsonSummary, indent string) bool {
+	if opts.PostHook == "" {
+		return true
+	}
+	code, err := runPostHook(opts, input, output, modified)
+	summary.HookExit = &code
+	if err == nil {
+		sayf("%s🪝 -post-hook выполнен (код выхода 0)\n", indent)
+		return true
+	}
+	if opts.HookStrict {
+		sayf("%s❌ %v\n", indent, err)
+		return false
+	}
+	sayf("%s⚠️  %v\n", indent, err)
+	return true
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestIdempotent проверяет, что повторный запуск по результату первого
+// ничего не добавляет и не меняет ни байта, в каждом формате записей
+func TestIdempotent(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		args []string
+	}{
+		{"compact", "testdata/compact_comments.yaml", nil},
+		{"multiline", "testdata/header_comment.yaml", nil},
+		{"mixed", "testdata/mixed_entries.yaml", nil},
+		{"flow", "testdata/flow_sequence.yaml", nil},
+		{"flow inline", "testdata/inline_flow.yaml", nil},
+		{"multi-document", "testdata/multi_document.yaml", nil},
+		{"multi-document -document 2", "testdata/multi_document.yaml", []string{"-document", "2"}},
+	}
+	for _, c := range cases {
+		data, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := testOptions(t, c.args...)
+		first, err := processDocument(string(data), opts)
+		if err != nil {
+			t.Fatalf("%s: первый запуск: %v", c.name, err)
+		}
+		if first.Modified == 0 {
+			t.Errorf("%s: первый запуск ничего не добавил", c.name)
+		}
+		second, err := processDocument(first.Content, opts)
+		if err != nil {
+			t.Fatalf("%s: второй запуск: %v", c.name, err)
+		}
+		if second.Modified != 0 {
+			t.Errorf("%s: второй запуск добавил поле %d раз", c.name, second.Modified)
+		}
+		if second.Content != first.Content {
+			t.Errorf("%s: второй запуск изменил результат:\n--- первый\n%s\n--- второй\n%s", c.name, first.Content, second.Content)
+		}
+	}
+}
+
+// TestDeterministic проверяет, что с -deterministic два запуска пакетной
+// обработки пишут одинаковые байты результатов, отчета -report и архива
+// -bundle, хотя между ними проходит время
+func TestDeterministic(t *testing.T) {
+	run := func() map[string][]byte {
+		dir := t.TempDir()
+		out := filepath.Join(dir, "out")
+		opts := testOptions(t, "-yes", "-deterministic", "-recursive", "-dir", "testdata/depth", "-out-dir", out,
+			"-report", filepath.Join(dir, "report.json"), "-bundle", filepath.Join(dir, "bundle.zip"))
+		if code := runBatch(opts); code != 0 {
+			t.Fatalf("код выхода %d", code)
+		}
+		files := map[string][]byte{}
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, path)
+			files[rel], err = os.ReadFile(path)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return files
+	}
+	first := run()
+	// Без -deterministic время в отчете и архиве за секунду изменилось бы
+	time.Sleep(1100 * time.Millisecond)
+	second := run()
+	if len(first) != len(second) {
+		t.Fatalf("записано %d и %d файлов", len(first), len(second))
+	}
+	for name, data := range first {
+		if !bytes.Equal(data, second[name]) {
+			t.Errorf("%s различается между запусками", name)
+		}
+	}
+	if len(first) < 4 {
+		t.Errorf("записано файлов: %d, want результаты, отчет и архив", len(first))
+	}
+}
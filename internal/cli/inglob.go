@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// globPick — файл, выбранный по шаблону из -in
+type globPick struct {
+	Pattern string
+	Path    string
+	Matches int // сколько файлов подошло под шаблон
+}
+
+// isGlob сообщает, что путь — шаблон: содержит *, ? или [
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expandInputs заменяет шаблоны в -in (config-*.yaml) на подходящий файл.
+// Каждый шаблон дает ровно один файл: если подошло несколько, без -newest
+// это ошибка, а с -newest берется файл, измененный последним. Путь, который
+// существует как есть, шаблоном не считается. Результаты (*.fixed.yaml),
+// резервные копии и временные файлы не выбираются.
+func expandInputs(opts *options) error {
+	for i, path := range opts.Inputs {
+		if !isGlob(path) {
+			continue
+		}
+		if _, err := os.Lstat(path); err == nil {
+			continue
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return fmt.Errorf("неверный шаблон -in %s: %v", path, err)
+		}
+		var files []string
+		for _, match := range matches {
+			// Результаты, резервные копии и временные файлы прошлых запусков
+			// под шаблон не подходят, как и при обходе -dir
+			if name := filepath.Base(match); isFixedName(name) || isBackupName(name) || isTempName(name) {
+				continue
+			}
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				files = append(files, match)
+			}
+		}
+		switch {
+		case len(files) == 0:
+			return fmt.Errorf("нет файлов по шаблону -in %s", path)
+		case len(files) > 1 && !opts.Newest:
+			return fmt.Errorf("под шаблон -in %s подходит несколько файлов (%s): уточните шаблон или добавьте -newest",
+				path, strings.Join(files, ", "))
+		}
+		picked, err := newestFile(files)
+		if err != nil {
+			return err
+		}
+		opts.Inputs[i] = picked
+		opts.GlobPicks = append(opts.GlobPicks, globPick{Pattern: path, Path: picked, Matches: len(files)})
+	}
+	return nil
+}
+
+// newestFile возвращает файл, измененный последним; при равном времени
+// изменения — последний по имени
+func newestFile(files []string) (string, error) {
+	sort.Strings(files)
+	picked := ""
+	var pickedTime int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		if t := info.ModTime().UnixNano(); picked == "" || t >= pickedTime {
+			picked, pickedTime = file, t
+		}
+	}
+	return picked, nil
+}
+
+// printGlobPicks сообщает, какие файлы выбраны по шаблонам -in
+func printGlobPicks(opts *options) {
+	for _, pick := range opts.GlobPicks {
+		if pick.Matches > 1 {
+			sayf("🔎 -in %s: выбран самый новый из подходящих (%d) — %s\n", pick.Pattern, pick.Matches, pick.Path)
+		} else {
+			sayf("🔎 -in %s: выбран %s\n", pick.Pattern, pick.Path)
+		}
+	}
+	if len(opts.GlobPicks) > 0 {
+		say()
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestExpandInputsSkipsOutputs проверяет, что шаблон -in не выбирает
+// результаты и резервные копии прошлых запусков
+func TestExpandInputsSkipsOutputs(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"config-1.yaml", "config-1.fixed.yaml", "config-1.yaml.backup"}
+	now := time.Now()
+	for i, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("proxies: []\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Результаты новее конфига: с -newest они выиграли бы по времени
+		stamp := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := filepath.Join(dir, "config-1.yaml")
+	for _, args := range [][]string{nil, {"-newest"}} {
+		opts := testOptions(t, args...)
+		opts.Inputs = []string{filepath.Join(dir, "config-*")}
+		if err := expandInputs(opts); err != nil {
+			t.Errorf("%v: %v", args, err)
+			continue
+		}
+		if opts.Inputs[0] != want {
+			t.Errorf("%v: выбран %s, want %s", args, opts.Inputs[0], want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"os"
+	"strings"
+)
+
+// defaultInput — входной файл, если файлы не указаны
+const defaultInput = "x509_no_fix.yaml"
+
+// starterConfig — пример конфига из инструкции; -init записывает его
+// в defaultInput, чтобы было с чего начать
+const starterConfig = `proxies:
+  - { name: Server1, type: trojan, server: s1.com, port: 443, password: pass1 }
+  - { name: Server2, type: vmess, server: s2.com, port: 443, uuid: xxxxx }
+`
+
+// printStarterConfig выводит пример конфига построчно
+func printStarterConfig() {
+	for _, line := range strings.Split(strings.TrimSuffix(starterConfig, "\n"), "\n") {
+		say(line)
+	}
+}
+
+// runInit записывает пример конфига в defaultInput (-init). Существующий
+// файл перезаписывается только с -force.
+func runInit(opts *options) int {
+	if _, err := os.Lstat(defaultInput); err == nil && !opts.Force {
+		sayf("⏭️  Файл %s уже есть и не перезаписан (перезаписать: -init -force)\n", defaultInput)
+		return 1
+	}
+	if err := writeResult(opts, defaultInput, []byte(starterConfig)); err != nil {
+		sayf("❌ Ошибка записи %s: %v\n", defaultInput, err)
+		return 1
+	}
+	sayf("✅ Создан пример конфига: %s\n", defaultInput)
+	say("   Замените прокси в нем своими и запустите программу снова")
+	return 0
+}
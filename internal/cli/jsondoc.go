@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// isJSONDocument проверяет, что конфиг целиком — JSON-объект (JSON тоже
+// YAML, такой конфиг пишут некоторые конвертеры подписок одной строкой)
+func isJSONDocument(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	return strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed))
+}
+
+// flowDocument проверяет, что весь документ записан в потоковом стиле
+// ({proxies: [...], ...}) — в том числе как JSON
+func flowDocument(content string) bool {
+	if !strings.HasPrefix(strings.TrimSpace(content), "{") {
+		return false
+	}
+	doc, err := loadDocument(content)
+	if err != nil || len(doc.Content) == 0 {
+		return false
+	}
+	return doc.Content[0].Kind == yaml.MappingNode && doc.Content[0].Style&yaml.FlowStyle != 0
+}
+
+// encodeDocument записывает измененный документ. JSON остается JSON:
+// в одну строку, если исходный был в одну строку, иначе с отступом в 2
+// пробела. Остальное сериализуется как YAML, комментарий в начале файла
+// восстанавливается байт в байт: при сериализации yaml.v3 меняет пустые
+// строки и отступы в нем.
+func encodeDocument(doc *yaml.Node, content string) (string, error) {
+	if isJSONDocument(content) {
+		var buf bytes.Buffer
+		if err := writeJSONNode(&buf, doc); err != nil {
+			return "", err
+		}
+		out := buf.Bytes()
+		if strings.Contains(strings.TrimSpace(content), "\n") {
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, out, "", "  "); err != nil {
+				return "", err
+			}
+			out = indented.Bytes()
+		}
+		return string(out) + lineEnding(content), nil
+	}
+	out, err := encodeNode(doc)
+	if err != nil {
+		return "", err
+	}
+	return leadingComments(content) + strings.TrimPrefix(out, leadingComments(out)), nil
+}
+
+// writeJSONNode записывает узел как JSON, сохраняя порядок ключей
+func writeJSONNode(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSONNode(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeJSONNode(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONValue(buf, node.Content[i].Value); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeJSONNode(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, child := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONNode(buf, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return fmt.Errorf("строка %d: %w", node.Line, err)
+		}
+		return writeJSONValue(buf, value)
+	}
+	return nil
+}
+
+// writeJSONValue записывает скалярное значение как JSON без экранирования
+// <, > и &: в конфиге они остаются как есть
+func writeJSONValue(buf *bytes.Buffer, value interface{}) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // Encode добавляет перевод строки
+	return nil
+}
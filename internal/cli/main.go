@@ -0,0 +1,474 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Main запускает err_x509 с флагами командной строки и завершает процесс
+// с кодом выхода. Вызывается только из cmd/err_x509.
+func Main() {
+	opts := parseFlags()
+	if opts.JSON || opts.Quiet || opts.BatchStdin {
+		console = io.Discard
+	}
+	if opts.BatchStdin {
+		os.Exit(runBatchStdin(os.Stdin, os.Stdout, opts))
+	}
+	say("╔══════════════════════════════════════════════╗")
+	say("║           err_x509 v1.1 - TLS Safe           ║")
+	say("║    SSL Certificate Verification Disabler     ║")
+	say("╚══════════════════════════════════════════════╝")
+	say()
+	say("📝 Добавляет 'skip-cert-verify: true' к прокси")
+	say("🛡️ Сохраняет все TLS/SSL параметры")
+	say("⚡ Быстро и безопасно")
+	sayf("🧭 Клиент: %s, -flavor %s\n", opts.flavor().Title, opts.Flavor)
+	say()
+	printGlobPicks(opts)
+
+	if opts.Init {
+		os.Exit(runInit(opts))
+	}
+	if opts.ListTLSTypes {
+		printTLSTypes()
+		os.Exit(0)
+	}
+	if opts.FromCSV != "" {
+		os.Exit(runFromCSV("x509_fixed.yaml", opts))
+	}
+	if opts.Compare != "" || opts.GroupCount != "" || opts.ValidateOnly || opts.ListMissing {
+		left := defaultInput
+		if len(opts.Inputs) == 1 {
+			left = opts.Inputs[0]
+		}
+		if opts.GroupCount != "" {
+			os.Exit(runGroupCount(left, opts))
+		}
+		if opts.ValidateOnly {
+			os.Exit(runValidate(left, opts))
+		}
+		if opts.ListMissing {
+			os.Exit(runListMissing(left, opts))
+		}
+		os.Exit(runCompare(left, opts))
+	}
+	if opts.batch() {
+		os.Exit(runBatch(opts))
+	}
+
+	// Конфигурационные файлы
+	inputFile := defaultInput
+	outputFile := "x509_fixed.yaml"
+	if opts.InPlace {
+		outputFile = inputFile
+	}
+	backupFile := inputFile + backupSuffix(opts.BackupFormat)
+
+	// Проверка входного файла
+	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
+		if opts.JSON {
+			log.Fatalf("❌ Файл конфигурации не найден: %s", inputFile)
+		}
+		say("❌ ОШИБКА: Файл конфигурации не найден!")
+		say()
+		say("📋 ИНСТРУКЦИЯ:")
+		say("1. Поместите ваш конфиг в файл '" + inputFile + "'")
+		say("2. Файл должен быть в той же папке, где находится программа")
+		say("3. Запустите программу снова")
+		say()
+		say("Пример файла " + inputFile + ":")
+		printStarterConfig()
+		say()
+		say("💡 Создать этот пример в текущей папке: err_x509 -init")
+		say()
+		pause(opts)
+		os.Exit(1)
+	}
+
+	for _, path := range []string{inputFile, outputFile, backupFile} {
+		if err := checkSymlink(path, opts); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+
+	// Недоступная для записи папка выявляется до обработки, а не на записи
+	if !opts.DryRun {
+		if err := checkWritable(outputFile, opts.Mkdir); err != nil {
+			log.Fatalf("❌ Результат %s нельзя записать: %v", outputFile, err)
+		}
+		if err := checkWritable(backupFile, opts.Mkdir); err != nil {
+			sayf("⚠️  Резервную копию %s не удастся записать: %v\n", backupFile, err)
+		}
+	}
+
+	cleanStaleTemps([]string{targetDir(outputFile), targetDir(backupFile)}, opts.DryRun)
+
+	// Чтение файла
+	sayf("📖 Чтение файла: %s\n", inputFile)
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Fatalf("❌ Ошибка чтения файла: %v", err)
+	}
+	originalContent, enc, layers, err := readInput(data, opts)
+	if err != nil {
+		log.Fatalf("❌ %s: %v", inputFile, err)
+	}
+	if len(layers) > 0 {
+		sayf("🧩 Распаковано: %s, результат будет записан как YAML\n", strings.Join(layers, " → "))
+	}
+	if !enc.isUTF8() {
+		sayf("🔤 Кодировка файла: %s, результат будет записан в ней же\n", enc.Name)
+	}
+	backupFile, backupFormat := patchFallback(backupFile, opts, enc, layers)
+	if backupFormat != opts.BackupFormat {
+		sayf("⚠️  Патч -backup-format patch для UTF-16 и -decode auto не строится, резервная копия будет полной: %s\n", backupFile)
+	}
+
+	say()
+	say("🔍 Поиск прокси для обработки...")
+	res, err := processFile(originalContent, inputFile, opts)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	content := res.Content
+
+	switch res.Format {
+	case "compact":
+		sayf("📋 Найдено прокси в компактном формате: %d\n", res.Found)
+	case "multiline":
+		sayf("📋 Найдено прокси в многострочном формате: %d\n", res.Found)
+	case "mixed":
+		sayf("📋 Найдено прокси в смешанном формате (компактные и многострочные записи): %d\n", res.Found)
+	case "structural":
+		sayf("📋 Найдено прокси (разбор YAML): %d\n", res.Found)
+	}
+
+	// Предупреждения
+	if len(res.Warnings) > 0 {
+		say()
+		for _, w := range res.Warnings {
+			sayf("⚠️  %s\n", w.text())
+		}
+	}
+
+	summary := jsonSummary{
+		Input:       inputFile,
+		Output:      outputFile,
+		Format:      res.Format,
+		Flavor:      opts.Flavor,
+		Modified:    res.Modified,
+		AlreadyHas:  res.AlreadyHas,
+		Total:       res.total(),
+		Stripped:    res.Stripped,
+		Protected:   res.Protected,
+		Skipped:     res.Skipped,
+		Filtered:    res.Filtered,
+		NoRequired:  res.NoRequired,
+		ServerOut:   res.ServerOut,
+		NoTLS:       res.NoTLS,
+		Removed:     res.Removed,
+		Duplicates:  res.Duplicates,
+		KeyDups:     res.KeyDups,
+		Bools:       res.Bools,
+		Blocks:      res.Blocks,
+		BlocksFixed: res.BlocksFixed,
+		Transformed: res.Transformed,
+		Warnings:    res.Warnings,
+		Changes:     opts.redactChanges(res.Changes),
+	}
+	if opts.ErrorHosts != nil {
+		summary.Matched = matchedProxies(res.Proxies, opts.ErrorHosts)
+	}
+
+	if opts.Strict && len(res.Warnings) > 0 {
+		say()
+		sayf("❌ Режим -strict: найдено предупреждений: %d, результат не сохранен\n", len(res.Warnings))
+		if opts.JSON {
+			printJSONSummary(summary, opts)
+		}
+		os.Exit(1)
+	}
+
+	// Отчет по прокси
+	if opts.Report != "" {
+		report := &proxyReport{}
+		report.noteInput(inputFile, originalContent, enc, layers, opts.EOL)
+		report.add(inputFile, res.Proxies, opts)
+		if err := writeReport(opts.Report, report, opts.ReportChanged, opts.jsonIndent(), opts.now()); err != nil {
+			log.Fatalf("❌ Ошибка записи отчета: %v", err)
+		}
+		sayf("📑 Отчет по прокси: %s\n", opts.Report)
+	}
+	if opts.ReportDiff != "" {
+		diffs := &diffArchive{}
+		diffs.add(filepath.Base(inputFile), inputFile, originalContent, content, opts)
+		if err := saveDiff(opts, diffs, ""); err != nil {
+			log.Fatalf("❌ Ошибка записи -report-diff: %v", err)
+		}
+	}
+	if opts.Bundle != "" {
+		bundle := &bundleArchive{}
+		err := bundle.add(filepath.Base(inputFile), inputFile, data, originalContent, content, enc, opts)
+		if err == nil {
+			err = saveBundle(opts, bundle, "")
+		}
+		if err != nil {
+			log.Fatalf("❌ Ошибка записи -bundle: %v", err)
+		}
+	}
+	if opts.EmitScript != "" {
+		script := &editScript{}
+		script.add(inputFile, res.Proxies, opts)
+		if err := script.write(opts.EmitScript); err != nil {
+			log.Fatalf("❌ Ошибка записи скрипта -emit-script: %v", err)
+		}
+		sayf("📜 Скрипт yq: %s, команд: %d\n", opts.EmitScript, script.edits)
+	}
+	if opts.StatsFile != "" {
+		if err := appendStats(opts.StatsFile, []jsonSummary{summary}); err != nil {
+			log.Fatalf("❌ Ошибка записи журнала -stats-file: %v", err)
+		}
+		sayf("📈 Журнал статистики дополнен: %s\n", opts.StatsFile)
+	}
+
+	// Статистика
+	say()
+	if res.total() > 0 && opts.RemoveIf != nil {
+		sayf("📊 СТАТИСТИКА ОБРАБОТКИ:\n")
+		sayf("   🗑️  Удалено поле у прокси (%s=%s): %d\n", opts.RemoveIf.Key, opts.RemoveIf.Value, res.Removed)
+		printRemovals(res.Proxies, "   ")
+		sayf("   ⏭️  Подошли под условие, но поля не было: %d\n", res.Skipped)
+		sayf("   ⏭️  Не подошли под условие: %d\n", res.Filtered)
+		if opts.serverFilter() {
+			sayf("   ⏭️  Пропущено фильтром по серверу: %d\n", res.ServerOut)
+		}
+		sayf("   📄 Всего найдено прокси: %d\n", res.total())
+		printDuplicates(res.Duplicates, "   ")
+		printKeyDuplicates(res.KeyDups, opts, "   ")
+		printNormalizedBools(opts, res.Bools, "   ")
+		printBlocks(opts, res, "   ")
+		printTransformed(res.Transformed, opts, "   ")
+	} else if res.total() > 0 {
+		sayf("📊 СТАТИСТИКА ОБРАБОТКИ:\n")
+		sayf("   ✅ Обработано прокси: %d\n", res.Modified)
+		sayf("   ⚡ Уже имели skip-cert-verify: %d\n", res.AlreadyHas)
+		if n := countNested(res.Proxies); n > 0 {
+			sayf("   🪆 Из них настроено во вложенном блоке (tls, reality-opts): %d\n", n)
+		}
+		if res.Anchor != "" {
+			sayf("   🔗 Подключен якорь: *%s\n", res.Anchor)
+		} else if opts.UseAnchor {
+			say("   🔗 Якорь с skip-cert-verify не найден, поле добавлено напрямую")
+		}
+		if opts.DenyServers != nil {
+			sayf("   🔒 Защищено списком -deny-servers: %d\n", res.Protected)
+		}
+		if opts.ValueMap != nil {
+			sayf("   🗺️  Значение из -map: %d\n", countMapValues(res.Proxies))
+		}
+		if n := countMarkerValues(res.Proxies); n > 0 {
+			sayf("   🎛️  Значение из комментария x509:value: %d\n", n)
+			printMarkerValues(res.Proxies)
+		}
+		if opts.ErrorHosts != nil {
+			sayf("   🎯 Совпало с журналом ошибок: %d\n", len(summary.Matched))
+			for _, name := range summary.Matched {
+				sayf("      • %s\n", name)
+			}
+			sayf("   ⏭️  Пропущено (нет в журнале ошибок): %d\n", res.Skipped)
+		}
+		if opts.nameFilter() {
+			sayf("   ⏭️  Пропущено фильтром по имени: %d\n", res.Filtered)
+		}
+		if opts.serverFilter() {
+			sayf("   ⏭️  Пропущено фильтром по серверу: %d\n", res.ServerOut)
+		}
+		if opts.OnlyTypeTLS {
+			sayf("   ⏭️  Пропущено без TLS (-only-type-tls): %d\n", res.NoTLS)
+			printTLSReasons(opts, res.Proxies, "      ")
+		}
+		printNoRequired(opts, res.Proxies, res.NoRequired, "   ")
+		sayf("   📄 Всего найдено прокси: %d\n", res.total())
+		printDuplicates(res.Duplicates, "   ")
+		printKeyDuplicates(res.KeyDups, opts, "   ")
+		printNormalizedBools(opts, res.Bools, "   ")
+		printBlocks(opts, res, "   ")
+		if len(opts.KeepFields) > 0 {
+			sayf("   ✂️  Удалено полей: %d\n", res.Stripped)
+		}
+		printTransformed(res.Transformed, opts, "   ")
+	} else if len(res.Transformed) > 0 {
+		sayf("📊 СТАТИСТИКА ОБРАБОТКИ:\n")
+		printTransformed(res.Transformed, opts, "   ")
+	} else if res.EmptySection {
+		sayf("📭 Секции прокси нет, добавлен пустой список %s: []\n", opts.ProxiesKey)
+	} else {
+		say("⚠️  ВНИМАНИЕ: Прокси не найдены!")
+		say()
+		say("Возможные причины:")
+		say("1. Файл уже содержит skip-cert-verify: true для всех прокси")
+		say("2. Формат файла не распознан")
+		say("3. В файле нет секции 'proxies:'")
+		say()
+		say("Поддерживаемые форматы:")
+		say("• Компактный: - { name: ..., server: ..., port: ... }")
+		say("• Многострочный (частично)")
+	}
+
+	// Изменения по полям
+	if opts.Explain && len(res.Changes) > 0 {
+		say()
+		say("🧾 ИЗМЕНЕНИЯ ПО ПОЛЯМ:")
+		printExplain(opts.redactChanges(res.Changes))
+	}
+
+	// Пробный запуск: показываем изменения и ничего не записываем
+	if opts.DryRun {
+		say()
+		say("🔍 ИЗМЕНЕНИЯ (пробный запуск, файлы не изменены):")
+		say("══════════════════════════════════════════════")
+		if opts.DiffOnlyChanged {
+			printChangedProxies(opts, res.Records)
+		} else if diff := renderDiff(opts, inputFile, outputFile, originalContent, content, res.Records); diff != "" {
+			sayf("%s", diff)
+		} else {
+			say("Изменений нет")
+		}
+		say("══════════════════════════════════════════════")
+		if err := writePreview(opts, inputFile, content, enc); err != nil {
+			log.Fatalf("❌ Ошибка записи -dry-run-out: %v", err)
+		}
+		webhookOK := summaryWebhook(opts, summary, "")
+		if opts.JSON {
+			printJSONSummary(summary, opts)
+		}
+		if !checkModifiedLimits(opts, res.Modified, res.total()) || !webhookOK {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !confirmOverwrite(opts, outputFile, backupFile) {
+		if opts.JSON {
+			printJSONSummary(summary, opts)
+		}
+		return
+	}
+
+	// Создаем резервную копию: исходного файла сейчас или, с -backup-mode
+	// previous-output, прежнего результата после успешной записи
+	previous, hasPrevious := readPrevious(outputFile)
+	if !opts.backupPrevious() {
+		say()
+		sayf("💾 Создание резервной копии: %s\n", backupFile)
+		backup := buildBackup(backupFormat, inputFile, outputFile, data, content, enc)
+		if err := writeResult(opts, backupFile, backup); err != nil {
+			if opts.KeepOriginal {
+				keepOriginalError(inputFile, "", fmt.Errorf("Не удалось создать резервную копию: %w", err))
+				os.Exit(1)
+			}
+			sayf("⚠️  Не удалось создать резервную копию: %v\n", err)
+		} else {
+			say("✅ Резервная копия создана")
+			summary.Backup = backupFile
+		}
+	}
+
+	// Сохранение результата: с -keep-original-on-error любая ошибка
+	// оставляет исходный файл как есть (saveOutput)
+	say()
+	sayf("💾 Сохранение результата: %s\n", outputFile)
+	err = saveOutput(opts, outputFile, inputFile, originalContent, content, enc, checkResult,
+		func(path string, data []byte) error { return writeResult(opts, path, data) })
+	if err != nil && opts.KeepOriginal {
+		keepOriginalError(inputFile, backupFile, err)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	summary.Written = true
+
+	if opts.backupPrevious() {
+		if !hasPrevious {
+			say("⏭️  Прежнего результата нет, резервная копия не обновлена")
+		} else if err := writeResult(opts, backupFile, previous); err != nil {
+			sayf("⚠️  Не удалось сохранить прежний результат в %s: %v\n", backupFile, err)
+		} else {
+			sayf("💾 Прежний результат сохранен в резервной копии: %s\n", backupFile)
+			summary.Backup = backupFile
+		}
+	}
+	hookOK := postHook(opts, inputFile, outputFile, res.Modified, &summary, "")
+	hookOK = summaryWebhook(opts, summary, "") && hookOK
+
+	if opts.JSON {
+		if err := printJSONSummary(summary, opts); err != nil {
+			log.Fatalf("❌ Ошибка вывода JSON: %v", err)
+		}
+		if !checkModifiedLimits(opts, res.Modified, res.total()) || !hookOK {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Показ путей к файлам
+	absInput, _ := filepath.Abs(inputFile)
+	absOutput, _ := filepath.Abs(outputFile)
+
+	say()
+	say("✅ ВЫПОЛНЕНО УСПЕШНО!")
+	say("══════════════════════════════════════════════")
+	sayf("📂 Исходный файл: %s\n", absInput)
+	sayf("📂 Результат: %s\n", absOutput)
+	if _, err := os.Stat(backupFile); err == nil {
+		absBackup, _ := filepath.Abs(backupFile)
+		sayf("📂 Резервная копия: %s\n", absBackup)
+	}
+	say("══════════════════════════════════════════════")
+
+	// Показ примера изменений
+	if res.Modified > 0 && opts.DiffOnlyChanged {
+		say()
+		say("🔍 ИЗМЕНЕННЫЕ ПРОКСИ:")
+		say("══════════════════════════════════════════════")
+		printChangedProxies(opts, res.Records)
+		say("══════════════════════════════════════════════")
+	} else if res.Modified > 0 {
+		say()
+		say("🔍 ПРИМЕР ИЗМЕНЕНИЙ:")
+		say("══════════════════════════════════════════════")
+
+		// Находим первый измененный прокси для примера
+		oldLines := strings.Split(originalContent, "\n")
+		newLines := strings.Split(content, "\n")
+
+		for i := 0; i < len(oldLines) && i < len(newLines); i++ {
+			if oldLines[i] != newLines[i] && hasField(newLines[i], "skip-cert-verify") {
+				// Находим соответствующий старый прокси
+				for j := i; j >= 0; j-- {
+					if hasField(oldLines[j], "name") && hasField(oldLines[j], "server") {
+						say("ДО: " + opts.redactText(strings.TrimSpace(oldLines[j])))
+						say("ПОСЛЕ: " + opts.redactText(strings.TrimSpace(newLines[i])))
+						break
+					}
+				}
+				break
+			}
+		}
+		say("══════════════════════════════════════════════")
+	}
+
+	say("🚀 Используйте файл '" + outputFile + "' в вашем клиенте")
+	say()
+	ok := checkModifiedLimits(opts, res.Modified, res.total()) && hookOK
+	pause(opts)
+	if !ok {
+		os.Exit(1)
+	}
+}
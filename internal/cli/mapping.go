@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// valueMap — значения skip-cert-verify для отдельных прокси из -map:
+// по имени прокси или по серверу (колонка из заголовка CSV)
+type valueMap struct {
+	byServer bool
+	values   map[string]string
+}
+
+// loadValueMap читает CSV с заголовком name,skip или server,skip.
+// Значение skip: true/false (также yes/no, 1/0).
+func loadValueMap(path string) (*valueMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%s: пустой файл", path)
+	}
+
+	header := rows[0]
+	if len(header) < 2 || strings.ToLower(strings.TrimSpace(header[1])) != "skip" {
+		return nil, fmt.Errorf("%s: ожидается заголовок name,skip или server,skip", path)
+	}
+	m := &valueMap{values: map[string]string{}}
+	switch column := strings.ToLower(strings.TrimSpace(header[0])); column {
+	case "name":
+	case "server":
+		m.byServer = true
+	default:
+		return nil, fmt.Errorf("%s: первая колонка должна быть name или server, а не %q", path, header[0])
+	}
+
+	for i, row := range rows[1:] {
+		if len(row) < 2 {
+			return nil, fmt.Errorf("%s:%d: ожидается две колонки", path, i+2)
+		}
+		value, ok := parseBool(row[1])
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: неверное значение skip %q (допустимо: true, false)", path, i+2, row[1])
+		}
+		m.values[m.key(row[0])] = value
+	}
+	return m, nil
+}
+
+// key нормализует ключ: имена сравниваются как есть, серверы — без учета регистра
+func (m *valueMap) key(s string) string {
+	s = strings.TrimSpace(s)
+	if m.byServer {
+		return strings.ToLower(s)
+	}
+	return s
+}
+
+// lookup возвращает значение для прокси из таблицы
+func (m *valueMap) lookup(name, server string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	key := name
+	if m.byServer {
+		key = server
+	}
+	value, ok := m.values[m.key(key)]
+	return value, ok
+}
+
+// parseBool приводит логическое значение к true/false
+func parseBool(s string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "1":
+		return "true", true
+	case "false", "no", "0":
+		return "false", true
+	}
+	return "", false
+}
+
+// mapConflict возвращает предупреждение, если у прокси уже задано значение,
+// отличное от значения из -map
+func mapConflict(name string, line int, existing, mapped string) (warning, bool) {
+	if value, ok := parseBool(existing); !ok || value == mapped {
+		return warning{}, false
+	}
+	return warning{
+		Kind:    warnMapConflict,
+		Proxy:   name,
+		Line:    line,
+		Value:   existing,
+		Message: fmt.Sprintf("skip-cert-verify: %s, а в -map указано %s; значение не изменено", existing, mapped),
+	}, true
+}
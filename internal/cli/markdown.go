@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// yamlFencePattern — открывающая строка блока кода ```yaml (или ~~~yml)
+var yamlFencePattern = regexp.MustCompile("(?m)^[ \t]*(```+|~~~+)[ \t]*ya?ml[ \t]*\r?$")
+
+// fenceOpenPattern — открывающая строка любого блока кода: ограждение
+// и строка языка после него
+var fenceOpenPattern = regexp.MustCompile("^[ \t]*(```+|~~~+)[ \t]*([^`\r\n]*?)[ \t]*\r?$")
+
+// errNoYAMLFence — в markdown нет блока кода с YAML
+var errNoYAMLFence = errors.New("в markdown не найден блок кода ```yaml")
+
+// markdownInput — markdown-документ, разделенный вокруг первого блока ```yaml
+type markdownInput struct {
+	before string // текст до содержимого блока, включая открывающую строку
+	block  string // YAML внутри блока
+	after  string // закрывающая строка и весь текст после нее
+}
+
+// isMarkdownInput проверяет, читать ли файл как markdown (-input-format)
+func isMarkdownInput(path string, opts *options) bool {
+	switch opts.InputFormat {
+	case "markdown":
+		return true
+	case "auto":
+		ext := strings.ToLower(filepath.Ext(path))
+		return ext == ".md" || ext == ".markdown"
+	}
+	return false
+}
+
+// splitMarkdown находит первый блок ```yaml и делит документ вокруг него.
+// Блок закрывается строкой из тех же символов ограждения не короче открывающей;
+// незакрытый блок продолжается до конца документа.
+func splitMarkdown(text string) (markdownInput, error) {
+	m := yamlFencePattern.FindStringSubmatchIndex(text)
+	if m == nil {
+		return markdownInput{}, errNoYAMLFence
+	}
+	fence := text[m[2]:m[3]]
+	start := m[1]
+	if start < len(text) {
+		start++ // перевод строки после открывающей строки
+	}
+
+	end := len(text)
+	for pos := start; pos < len(text); {
+		lineEnd := strings.IndexByte(text[pos:], '\n')
+		next := len(text)
+		if lineEnd >= 0 {
+			next = pos + lineEnd + 1
+		}
+		line := strings.TrimSpace(text[pos:next])
+		if strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == "" {
+			end = pos
+			break
+		}
+		pos = next
+	}
+	return markdownInput{before: text[:start], block: text[start:end], after: text[end:]}, nil
+}
+
+// fencedBlock — содержимое блока кода ```yaml: границы в байтах
+type fencedBlock struct {
+	start, end int
+}
+
+// markdownBlocks находит все блоки ```yaml (и ~~~yml) для -markdown.
+// Блоки на других языках пропускаются целиком: строка ```yaml внутри
+// блока ```text — часть его текста, а не новый блок.
+func markdownBlocks(text string) []fencedBlock {
+	var blocks []fencedBlock
+	fence, isYAML, start := "", false, 0
+	for pos := 0; pos < len(text); {
+		next := len(text)
+		if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+			next = pos + i + 1
+		}
+		line := text[pos:next]
+		if fence == "" {
+			if m := fenceOpenPattern.FindStringSubmatch(strings.TrimRight(line, "\n")); m != nil {
+				fence, start = m[1], next
+				isYAML = m[2] == "yaml" || m[2] == "yml"
+			}
+		} else if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			if isYAML {
+				blocks = append(blocks, fencedBlock{start, pos})
+			}
+			fence = ""
+		}
+		pos = next
+	}
+	// Незакрытый блок продолжается до конца документа
+	if fence != "" && isYAML {
+		blocks = append(blocks, fencedBlock{start, len(text)})
+	}
+	return blocks
+}
+
+// processMarkdown обрабатывает каждый блок ```yaml документа (-markdown):
+// текст вокруг блоков и блоки на других языках не меняются. Итоги блоков
+// складываются, номера строк считаются от начала документа.
+func processMarkdown(content string, opts *options) (fixResult, error) {
+	blocks := markdownBlocks(content)
+	if len(blocks) == 0 {
+		return fixResult{Content: content}, errNoYAMLFence
+	}
+	res := fixResult{}
+	var sb strings.Builder
+	prev := 0
+	for i, b := range blocks {
+		line := strings.Count(content[:b.start], "\n")
+		block, err := processDocument(content[b.start:b.end], opts)
+		if err != nil {
+			return block, fmt.Errorf("блок ```yaml на строке %d: %w", line, err)
+		}
+		block.shiftLines(line)
+		if opts.TrimTrailing {
+			block.Content = trimTrailingWhitespace(block.Content)
+		}
+		if block.Content != content[b.start:b.end] {
+			res.BlocksFixed++
+		}
+		sb.WriteString(content[prev:b.start])
+		sb.WriteString(block.Content)
+		prev = b.end
+		if i == 0 {
+			block.BlocksFixed = res.BlocksFixed
+			res = block
+		} else {
+			res.merge(block)
+		}
+	}
+	sb.WriteString(content[prev:])
+	res.Content = setLineEnding(sb.String(), opts.EOL)
+	res.Blocks = len(blocks)
+	return res, nil
+}
+
+// merge добавляет к результату итоги следующего блока (-markdown)
+func (r *fixResult) merge(o fixResult) {
+	if r.Format == "" {
+		r.Format = o.Format
+	} else if o.Format != "" && o.Format != r.Format {
+		r.Format = "mixed"
+	}
+	r.Found += o.Found
+	r.Modified += o.Modified
+	r.AlreadyHas += o.AlreadyHas
+	r.Stripped += o.Stripped
+	r.Protected += o.Protected
+	r.Skipped += o.Skipped
+	r.Filtered += o.Filtered
+	r.Removed += o.Removed
+	r.NoRequired += o.NoRequired
+	r.ServerOut += o.ServerOut
+	r.NoTLS += o.NoTLS
+	r.Bools += o.Bools
+	if r.Anchor == "" {
+		r.Anchor = o.Anchor
+	}
+	r.Duplicates = append(r.Duplicates, o.Duplicates...)
+	r.KeyDups = append(r.KeyDups, o.KeyDups...)
+	r.Warnings = append(r.Warnings, o.Warnings...)
+	r.Records = append(r.Records, o.Records...)
+	r.Changes = append(r.Changes, o.Changes...)
+	r.Proxies = append(r.Proxies, o.Proxies...)
+	for name, n := range o.Transformed {
+		if r.Transformed == nil {
+			r.Transformed = map[string]int{}
+		}
+		r.Transformed[name] += n
+	}
+	r.EmptySection = r.EmptySection || o.EmptySection
+}
+
+// printBlocks выводит число блоков ```yaml, обработанных -markdown;
+// для файлов YAML ничего не выводится
+func printBlocks(opts *options, res fixResult, indent string) {
+	if opts.Markdown && res.Blocks > 0 {
+		sayf("%s🧱 Блоков ```yaml обработано: %d, изменено: %d\n", indent, res.Blocks, res.BlocksFixed)
+	}
+}
+
+// processFile обрабатывает содержимое входного файла path: YAML целиком или,
+// для markdown, YAML из первого блока ```yaml (с -markdown — из каждого). Номера строк в результате
+// отсчитываются от начала файла, а Content — полный текст результата
+// (markdown с обновленным блоком или только YAML с -output-format yaml).
+func processFile(content, path string, opts *options) (fixResult, error) {
+	if !isMarkdownInput(path, opts) {
+		res, err := processDocument(content, opts)
+		if opts.TrimTrailing {
+			res.Content = trimTrailingWhitespace(res.Content)
+		}
+		res.Content = setLineEnding(res.Content, opts.EOL)
+		return res, err
+	}
+	if opts.Markdown {
+		return processMarkdown(content, opts)
+	}
+	md, err := splitMarkdown(content)
+	if err != nil {
+		return fixResult{Content: content}, err
+	}
+	res, err := processDocument(md.block, opts)
+	if err != nil {
+		return res, err
+	}
+	res.shiftLines(strings.Count(md.before, "\n"))
+	// В markdown два пробела в конце строки — перенос: чистится только блок YAML
+	if opts.TrimTrailing {
+		res.Content = trimTrailingWhitespace(res.Content)
+	}
+	if opts.OutputFormat != "yaml" {
+		res.Content = md.before + res.Content + md.after
+	}
+	res.Content = setLineEnding(res.Content, opts.EOL)
+	return res, nil
+}
+
+// shiftLines сдвигает номера строк в результате на offset
+func (r *fixResult) shiftLines(offset int) {
+	for i := range r.Warnings {
+		r.Warnings[i].Line += offset
+	}
+	for i := range r.Records {
+		r.Records[i].Line += offset
+	}
+	for i := range r.Changes {
+		r.Changes[i].Line += offset
+	}
+	for i := range r.Proxies {
+		r.Proxies[i].Line += offset
+	}
+	for i := range r.Duplicates {
+		r.Duplicates[i].Line += offset
+		r.Duplicates[i].First += offset
+	}
+	for i := range r.KeyDups {
+		r.KeyDups[i].Line += offset
+		for j := range r.KeyDups[i].Lines {
+			r.KeyDups[i].Lines[j] += offset
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// missingProxy — прокси без добавляемого поля (-list-missing)
+type missingProxy struct {
+	Name   string `json:"name"`
+	Type   string `json:"type,omitempty"`
+	Server string `json:"server,omitempty"`
+	Line   int    `json:"line"`
+	Status string `json:"status"` // modified — поле будет добавлено, иначе почему нет
+}
+
+// missingResult — итог -list-missing в режиме -json
+type missingResult struct {
+	Input   string         `json:"input"`
+	Total   int            `json:"total"`
+	Missing []missingProxy `json:"missing"`
+}
+
+// findMissing возвращает прокси без поля. Используется тот же поиск, что
+// при добавлении: поле из якоря, вложенная настройка проверки и другое
+// написание ключа с -ignore-case-keys считаются наличием поля.
+func findMissing(res fixResult) []missingProxy {
+	missing := []missingProxy{}
+	for _, p := range res.Proxies {
+		if p.Status == statusAlready || p.Status == statusDuplicate {
+			continue
+		}
+		missing = append(missing, missingProxy{Name: p.Name, Type: p.Type, Server: p.Server, Line: p.Line, Status: p.Status})
+	}
+	return missing
+}
+
+// missingNote поясняет, почему прокси без поля не будет изменен
+func missingNote(status string) string {
+	switch status {
+	case statusProtected:
+		return "защищен -deny-servers"
+	case statusSkipped:
+		return "будет пропущен"
+	case statusFiltered:
+		return "не выбран фильтром по имени"
+	case statusNoField:
+		return "нет поля -require-field"
+	case statusServerOut:
+		return "не выбран фильтром по серверу"
+	case statusNoTLS:
+		return "без TLS (-only-type-tls)"
+	}
+	return ""
+}
+
+// runListMissing выводит прокси, у которых нет добавляемого поля, и
+// с -list-missing-out записывает их в CSV. Конфиг не изменяется; код
+// выхода 1 — такие прокси есть, 2 — ошибка чтения.
+func runListMissing(inputFile string, opts *options) int {
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ %s: %v\n", inputFile, err)
+		return 2
+	}
+	content, _, _, err := readInput(data, opts)
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ %s: %v\n", inputFile, err)
+		return 2
+	}
+	res, err := processFile(content, inputFile, opts)
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ %s: %v\n", inputFile, err)
+		return 2
+	}
+	missing := findMissing(res)
+	total := 0
+	for _, p := range res.Proxies {
+		if p.Status != statusDuplicate {
+			total++
+		}
+	}
+
+	if opts.ListMissingOut != "" {
+		if err := writeMissing(opts.ListMissingOut, missing); err != nil {
+			fmt.Fprintf(errConsole, "❌ Ошибка записи -list-missing-out: %v\n", err)
+			return 2
+		}
+	}
+	if opts.JSON {
+		if err := jsonEncoder(os.Stdout, opts).Encode(missingResult{Input: inputFile, Total: total, Missing: missing}); err != nil {
+			fmt.Fprintf(errConsole, "❌ Ошибка вывода JSON: %v\n", err)
+			return 2
+		}
+	} else {
+		sayf("🔎 ПРОКСИ БЕЗ ПОЛЯ %s: %s\n", opts.Field, inputFile)
+		say("══════════════════════════════════════════════")
+		if res.Format == "" && len(res.Proxies) == 0 {
+			say("Прокси не найдены")
+		} else if len(missing) == 0 {
+			say("✅ Поле есть у всех прокси")
+		}
+		for _, p := range missing {
+			line := fmt.Sprintf("• %s — %s (строка %d)", p.Name, p.Server, p.Line)
+			if note := missingNote(p.Status); note != "" {
+				line += ": " + note
+			}
+			say(line)
+		}
+		say("══════════════════════════════════════════════")
+		sayf("   📄 Всего прокси: %d, без поля: %d\n", total, len(missing))
+		if opts.ListMissingOut != "" {
+			sayf("   💾 Список записан: %s\n", opts.ListMissingOut)
+		}
+	}
+	if len(missing) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// writeMissing записывает прокси без поля в CSV
+func writeMissing(path string, missing []missingProxy) error {
+	var buf strings.Builder
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"name", "type", "server", "line", "status"})
+	for _, p := range missing {
+		w.Write([]string{p.Name, p.Type, p.Server, strconv.Itoa(p.Line), p.Status})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return writeFileMkdir(path, []byte(buf.String()), 0)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// boolSpellings — написания логических значений YAML 1.1 и их
+// каноническая запись для -normalize-bools (регистр не важен). y и n
+// не включены: большинство парсеров читают их как строки.
+var boolSpellings = map[string]string{
+	"true": "true", "yes": "true", "on": "true",
+	"false": "false", "no": "false", "off": "false",
+}
+
+// stringFields — поля, значения которых всегда строки: -normalize-bools
+// не превращает пароль "on" или имя прокси "no" в логическое значение
+var stringFields = map[string]bool{
+	"name": true, "type": true, "server": true, "servername": true, "sni": true,
+	"cipher": true, "username": true, "flow": true, "network": true,
+}
+
+// normalizeBools приводит логические значения во всем конфиге к true
+// и false (-normalize-bools): yes/no/on/off и True/FALSE без кавычек.
+// Значения в кавычках, с тегом !!str и значения строковых полей (имена,
+// серверы, пароли и другие секретные поля) не меняются. Документ
+// разбирается, чтобы найти значения, а заменяются они прямо в тексте:
+// форматирование и комментарии остаются. Возвращает число замен.
+func normalizeBools(content string) (string, int) {
+	doc, err := loadDocument(content)
+	if err != nil {
+		return content, 0
+	}
+	lineStarts := lineOffsets(content)
+	type edit struct {
+		start, end int
+		value      string
+	}
+	var edits []edit
+	var walk func(node *yaml.Node, key string)
+	walk = func(node *yaml.Node, key string) {
+		switch node.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, child := range node.Content {
+				walk(child, key)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], node.Content[i].Value)
+			}
+		case yaml.ScalarNode:
+			// Style != 0 — значение в кавычках, блочное или с явным тегом
+			canonical, ok := boolSpellings[strings.ToLower(node.Value)]
+			if !ok || canonical == node.Value || node.Style != 0 || protectedField(key) {
+				return
+			}
+			start := scalarStart(content, nodeOffset(content, lineStarts, node))
+			if start < 0 || !strings.HasPrefix(content[start:], node.Value) {
+				return
+			}
+			edits = append(edits, edit{start, start + len(node.Value), canonical})
+		}
+	}
+	walk(doc, "")
+	if len(edits) == 0 {
+		return content, 0
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		content = content[:e.start] + e.value + content[e.end:]
+	}
+	return content, len(edits)
+}
+
+// protectedField сообщает, что значение поля key — всегда строка
+func protectedField(key string) bool {
+	if stringFields[key] {
+		return true
+	}
+	for _, field := range defaultRedactFields {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
+
+// printNormalizedBools выводит число замен -normalize-bools
+func printNormalizedBools(opts *options, count int, indent string) {
+	if opts.NormalizeBools {
+		sayf("%s🧹 Приведено логических значений к true/false (-normalize-bools): %d\n", indent, count)
+	}
+}
+
+// scalarStart пропускает перед значением якорь &name
+func scalarStart(content string, pos int) int {
+	if pos < 0 {
+		return -1
+	}
+	for pos < len(content) && content[pos] == '&' {
+		for pos < len(content) && content[pos] != ' ' && content[pos] != '\t' && content[pos] != '\n' {
+			pos++
+		}
+		for pos < len(content) && (content[pos] == ' ' || content[pos] == '\t') {
+			pos++
+		}
+	}
+	return pos
+}
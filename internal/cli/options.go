@@ -0,0 +1,884 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Режимы -backup-mode
+const (
+	backupOriginal       = "original"        // копия исходного файла до записи
+	backupPreviousOutput = "previous-output" // прежний результат после успешной записи
+)
+
+// options — параметры запуска из командной строки
+type options struct {
+	BackupFormat     string
+	BackupMode       string // что сохраняется в копии: original, previous-output (-backup-mode)
+	JSON             bool
+	JSONIndent       int // пробелов отступа в выводе JSON, 0 — в одну строку (-json-indent)
+	Strict           bool
+	FailUnknownType  bool // -fail-on-unsupported-type
+	DryRun           bool
+	DryRunOut        string // файл для просмотра результата пробного запуска (-dry-run-out)
+	DiffOnlyChanged  bool
+	PrettyDiff       bool // diff пробного запуска в две колонки (-pretty-diff)
+	Explain          bool
+	Redact           []string // поля, значения которых скрываются в выводе (-redact)
+	Context          int
+	Wrap             int // длина строки, после которой компактная запись переносится (-wrap)
+	Document         int // обработать только этот документ YAML (с 1), 0 — все (-document)
+	KeepFields       []string
+	Transforms       []string         // шаги обработки по порядку (-transform)
+	RemoveIf         *removeCondition // удалить поле у прокси по условию (-field-remove-if)
+	DenyServers      *serverList
+	ErrorHosts       *serverList
+	IncludeName      *regexp.Regexp
+	ExcludeName      *regexp.Regexp
+	IncludeServer    serverGlobs
+	ExcludeServer    serverGlobs
+	OnlyTypeTLS      bool     // поле добавляется только прокси с TLS по таблице proxyTypes (-only-type-tls)
+	ListTLSTypes     bool     // вывести TLS типов из proxyTypes и выйти (-list-tls-types)
+	Verbose          bool     // подробный вывод: почему прокси выбран -only-type-tls (-verbose)
+	WarnSNIMismatch  bool     // предупреждать, что sni не совпадает с server (-warn-sni-mismatch)
+	ReconcileSNI     string   // какое из sni / servername заполнять из другого: both, sni, servername (-reconcile-sni)
+	RequireFields    []string // поле добавляется только прокси с одним из этих полей (-require-field)
+	MinModified      int
+	MaxModified      int
+	NoOpExitZero     bool // без прокси — код выхода 0 при любых порогах (-no-op-exit-zero)
+	UseAnchor        bool
+	DedupBy          string // удалять повторяющиеся прокси: name (-dedup-by)
+	DedupKeys        string // оставлять одно из повторяющихся полей прокси: last, first (-dedup-keys)
+	NormalizeBools   bool   // привести yes/no/on/off во всем конфиге к true/false (-normalize-bools)
+	FieldComment     string // комментарий к добавленному полю (-field-comment)
+	AliasList        string // список прокси по ссылке *якорь: anchor, inline (-alias-list)
+	Flavor           string // клиент, под который пишется результат: meta, premium (-flavor)
+	IgnoreCaseKeys   bool   // ключ поля без учета регистра, '-' и '_' (-ignore-case-keys)
+	Value            string
+	Field            string
+	FieldType        string
+	ValueMap         *valueMap
+	Placements       map[string]string // путь к полю по типу прокси (-placement)
+	FromCSV          string            // CSV, из которого строится конфиг (-from-csv)
+	Init             bool              // записать пример конфига в x509_no_fix.yaml (-init)
+	BatchStdin       bool              // запросы JSON lines из stdin (-batch-stdin)
+	Compare          string            // второй конфиг для сравнения (-compare)
+	CompareBy        string
+	GroupCount       string // поле, по которому считаются прокси (-group-count)
+	ListMissing      bool   // только вывести прокси без поля (-list-missing)
+	ListMissingOut   string // CSV для списка -list-missing (-list-missing-out)
+	ValidateOnly     bool   // только проверить поля прокси (-validate-only)
+	EmitEmptySection bool
+	Subconverter     bool
+	ProxiesKey       string
+	InputFormat      string
+	Decode           string
+	OutputFormat     string
+	Markdown         bool   // обрабатывать каждый блок ```yaml в markdown, а не только первый (-markdown)
+	EOL              string // перевод строки результата: preserve, lf, crlf (-eol)
+	TrimTrailing     bool   // убрать пробелы в конце строк (-trim-trailing-whitespace)
+	Report           string
+	ReportChanged    bool
+	Deterministic    bool // одинаковые байты при каждом запуске: фиксированное время (-deterministic)
+	StatsFile        string
+	EmitScript       string // скрипт yq, повторяющий изменения (-emit-script)
+	ReportDiff       string // файл или папка для unified diff (-report-diff)
+	ReportDiffSplit  bool   // diff по файлу на каждый входной файл (-report-diff-split)
+	Bundle           string // zip с исходными файлами, результатами и diff (-bundle)
+	PostHook         string // команда после успешной записи (-post-hook)
+	HookStrict       bool   // ошибка -post-hook — ошибка запуска (-hook-strict)
+	Webhook          string // адрес, на который отправляется итог (-summary-webhook)
+	WebhookHeader    string // заголовок запроса "Имя: значение" (-webhook-header)
+	WebhookStrict    bool   // итог не доставлен — ошибка запуска (-webhook-strict)
+	WebhookTimeout   time.Duration
+	Quiet            bool
+	ConfirmOverwrite bool
+	Force            bool
+	Yes              bool // отвечать «да» на все вопросы и не ждать Enter (-yes, -y)
+	InPlace          bool
+	KeepOriginal     bool // -keep-original-on-error
+	NoFollowSymlinks bool
+	Mkdir            bool        // создавать недостающие папки результата и копии (-mkdir)
+	FileMode         os.FileMode // права результатов и копий; 0 — по умолчанию
+
+	// Пакетный режим
+	Inputs        []string // файлы из -in и аргументов
+	Profiles      string   // индекс профилей, пути из которого добавляются в Inputs (-profiles)
+	ProfilesKey   string   // ключ списка путей в индексе (-profiles-key)
+	Newest        bool     // из нескольких файлов по шаблону -in брать самый новый (-newest)
+	GlobPicks     []globPick
+	Dir           string
+	Recursive     bool
+	MaxDepth      int // глубина обхода -dir; -1 — без ограничения (-max-depth)
+	OutDir        string
+	BackupDir     string
+	Passthrough   bool
+	SkipNoProxies bool
+	State         string
+	KeepGoing     bool
+}
+
+// parseFlags разбирает аргументы командной строки
+func parseFlags() *options {
+	return parseOptions(flag.CommandLine, os.Args[1:])
+}
+
+// defaultOptions возвращает параметры, как после разбора командной строки
+// без флагов (TestDefaultOptions сверяет их с parseOptions). Fix строит
+// параметры из них: разбор флагов при ошибке завершает процесс.
+func defaultOptions() *options {
+	return &options{
+		BackupFormat:   "copy",
+		BackupMode:     backupOriginal,
+		WebhookTimeout: 10 * time.Second,
+		JSONIndent:     2,
+		Flavor:         flavorMeta,
+		AliasList:      aliasListAnchor,
+		Context:        3,
+		Value:          "true",
+		ProxiesKey:     "proxies",
+		InputFormat:    "yaml",
+		MaxModified:    -1,
+		MaxDepth:       -1,
+		ProfilesKey:    "profiles",
+		Field:          defaultPlacement,
+		FieldType:      fieldAuto,
+		EOL:            "preserve",
+		Decode:         "none",
+		CompareBy:      "name",
+		Placements:     map[string]string{},
+	}
+}
+
+// parseOptions регистрирует флаги в fs и разбирает args. Неверные флаги
+// и их сочетания — сообщение и выход с кодом 2.
+func parseOptions(fs *flag.FlagSet, args []string) *options {
+	opts := &options{}
+
+	fs.StringVar(&opts.BackupFormat, "backup-format", "copy",
+		"формат резервной копии: copy — полная копия исходного файла,\n"+
+			"patch — unified diff, из которого исходный файл восстанавливается\n"+
+			"командой patch -R <результат> <патч>")
+	fs.StringVar(&opts.BackupMode, "backup-mode", backupOriginal,
+		"что хранит резервная копия: original — исходный файл (копия пишется до записи\n"+
+			"результата), previous-output — прежний результат: копия обновляется только\n"+
+			"после успешной записи и позволяет откатиться на шаг назад")
+	fs.StringVar(&opts.ReportDiff, "report-diff", "", "сохранить unified diff изменений в файл (и с -dry-run); для папки — в\n"+
+		"err_x509-<время>.diff в ней. Diff применяется к исходным файлам командой patch -p0")
+	fs.BoolVar(&opts.ReportDiffSplit, "report-diff-split", false, "с -report-diff: записать в папку err_x509-<время> внутри -report-diff\n"+
+		"отдельный <файл>.diff на каждый измененный файл вместо общего diff")
+	fs.StringVar(&opts.Bundle, "bundle", "", "записать zip для проверки изменений (и с -dry-run): original/<файл> — исходный файл,\n"+
+		"fixed/<файл> — результат, changes.diff — общий diff, как у -report-diff")
+	fs.StringVar(&opts.PostHook, "post-hook", "", "команда, которая запускается после успешной записи результата (например,\n"+
+		"перезапуск клиента); аргументы разбираются как в sh, но без оболочки. Переменные\n"+
+		"окружения: ERR_X509_INPUT, ERR_X509_OUTPUT, ERR_X509_MODIFIED")
+	fs.BoolVar(&opts.HookStrict, "hook-strict", false, "с -post-hook: ненулевой код выхода команды — ошибка запуска (код выхода 1)")
+	fs.StringVar(&opts.Webhook, "summary-webhook", "", "после успешного запуска отправить итог (JSON, как у -json) POST-запросом на этот адрес")
+	fs.DurationVar(&opts.WebhookTimeout, "webhook-timeout", 10*time.Second, "с -summary-webhook: время ожидания ответа (например, 5s)")
+	fs.StringVar(&opts.WebhookHeader, "webhook-header", "", "с -summary-webhook: заголовок запроса \"Имя: значение\", например \"Authorization: Bearer ...\";\n"+
+		"если не задан, берется из переменной окружения "+webhookHeaderEnv+". Значение в вывод не попадает")
+	fs.BoolVar(&opts.WebhookStrict, "webhook-strict", false, "с -summary-webhook: итог не доставлен — ошибка запуска (код выхода 1)")
+	fs.BoolVar(&opts.JSON, "json", false, "вывести итог обработки в формате JSON (вместо текстового отчета)")
+	fs.IntVar(&opts.JSONIndent, "json-indent", 2, "отступ в выводе JSON (-json, отчет -report): N пробелов, 0 — в одну строку")
+	jsonCompact := fs.Bool("json-compact", false, "выводить JSON в одну строку (то же, что -json-indent 0)")
+	fs.BoolVar(&opts.Strict, "strict", false, "считать любые предупреждения ошибками: результат не сохраняется, код выхода 1.\n"+
+		"Предупреждения: unknown-type — неизвестный тип прокси, duplicate-name — повторяющееся\n"+
+		"имя, missing-ref — группа ссылается на несуществующий прокси, parse-skip — запись\n"+
+		"в секции прокси не распознана, parse-error — YAML не разбирается,\n"+
+		"map-conflict — значение прокси расходится с -map, placement — поле -placement\n"+
+		"некуда добавить, unterminated — запись { ... } не закрыта до конца файла,\n"+
+		"flavor — тип прокси не поддерживается клиентом -flavor, alias-list — список прокси\n"+
+		"задан ссылкой на якорь, duplicate-key — поле задано в записи прокси несколько раз,\n"+
+		"malformed — прокси, которого текстовый способ не нашел в необычной записи,\n"+
+		"sni-mismatch — sni не совпадает с server (-warn-sni-mismatch), sni-conflict —\n"+
+		"sni и servername расходятся (-reconcile-sni)")
+	fs.BoolVar(&opts.FailUnknownType, "fail-on-unsupported-type", false, "завершиться с ошибкой, если в конфиге есть прокси неизвестного типа:\n"+
+		"выводятся типы и имена прокси, файлы не записываются")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "только показать изменения (unified diff), ничего не записывая")
+	fs.StringVar(&opts.DryRunOut, "dry-run-out", "", "записать результат в этот файл для просмотра; результат и резервная\n"+
+		"копия не записываются (включает -dry-run)")
+	fs.BoolVar(&opts.DiffOnlyChanged, "diff-only-changed", false, "показывать только измененные прокси (имя, до и после),\n"+
+		"упорядоченные по имени, вместо полного diff или примера")
+	fs.BoolVar(&opts.Explain, "explain", false, "показать каждое изменение по полям: прокси, поле, старое и новое значение, действие")
+	redact := fs.Bool("redact", false, "скрывать значения секретных полей (пароли, uuid, ключи) в diff, списке измененных\n"+
+		"прокси, -explain, итоге -json и отчете -report: password: ****. Результат\n"+
+		"записывается с настоящими значениями")
+	redactFields := fs.String("redact-fields", strings.Join(defaultRedactFields, ","),
+		"поля, которые скрывает -redact (через запятую); указанный список включает -redact")
+	fs.StringVar(&opts.Flavor, "flavor", flavorMeta, "клиент, который будет читать результат: meta — Mihomo (Clash.Meta),\n"+
+		"premium — Clash Premium. Прокси типов, которых клиент не знает, поле не получают\n"+
+		"(предупреждение flavor), а -field с его неизвестным полем не принимается")
+	fs.StringVar(&opts.FieldComment, "field-comment", "", "комментарий в строке добавленного поля, например \"added by err_x509\";\n"+
+		"у компактной записи — после '}', если в строке нет своего комментария")
+	fs.StringVar(&opts.AliasList, "alias-list", aliasListAnchor, "если список прокси задан ссылкой (proxies: *all): anchor — изменить\n"+
+		"определение якоря (и все ссылки на него), inline — заменить ссылку измененной копией")
+	fs.StringVar(&opts.DedupBy, "dedup-by", "", "удалить повторяющиеся прокси до обработки, оставив первый: name — с тем же\n"+
+		"именем. Повторы с другими полями дают предупреждение duplicate-name")
+	fs.BoolVar(&opts.NormalizeBools, "normalize-bools", false, "привести логические значения во всем конфиге к true/false: yes/no, on/off,\n"+
+		"True/FALSE без кавычек. Значения в кавычках и строковых полей (name, server, пароли) не меняются")
+	fs.StringVar(&opts.DedupKeys, "dedup-keys", "", "если поле задано в записи прокси несколько раз (например, skip-cert-verify\n"+
+		"после другой программы), оставить одно: last — последнее, first — первое")
+	fs.IntVar(&opts.Document, "document", 0, "в файле из нескольких документов YAML (разделены строками ---) обработать\n"+
+		"только документ N, считая с 1; остальные не меняются. 0 — все документы")
+	fs.IntVar(&opts.Wrap, "wrap", 0, "компактные прокси, строка которых после добавления поля длиннее N символов,\n"+
+		"записать в многострочном виде; 0 — не переносить")
+	fs.BoolVar(&opts.PrettyDiff, "pretty-diff", false, "показывать diff пробного запуска в две колонки: слева исходный файл, справа\n"+
+		"результат; в узком окне (ширина из COLUMNS или терминала) — обычный diff")
+	fs.IntVar(&opts.Context, "context", 3, "число неизмененных строк вокруг каждого изменения в diff (как diff -U N)")
+	keepFields := fs.String("keep-fields", "", "оставить у прокси только перечисленные поля (через запятую),\n"+
+		"остальные удаляются; skip-cert-verify сохраняется всегда.\n"+
+		"Изменяет данные — сначала проверьте результат с -dry-run")
+	transforms := fs.String("transform", "", "шаги обработки через запятую, выполняются по порядку: add-skip-cert\n"+
+		"(добавление поля, по умолчанию), set-sni, strip-insecure, add-udp, reconcile-sni")
+	fs.StringVar(&opts.ReconcileSNI, "reconcile-sni", "", "если у прокси задано только одно из sni и servername, скопировать его\n"+
+		"в другое: both — в то, которого нет, sni или servername — только в это поле.\n"+
+		"Добавляет шаг reconcile-sni перед остальными; расхождение двух полей — предупреждение sni-conflict")
+	denyServers := fs.String("deny-servers", "", "файл со списком серверов (имя хоста или подсеть CIDR на строку),\n"+
+		"которым никогда не добавляется skip-cert-verify")
+	errorLog := fs.String("error-log", "", "журнал клиента с ошибками x509: skip-cert-verify добавляется только\n"+
+		"прокси, чей server встречается в этих ошибках")
+	fs.StringVar(&opts.Value, "value", "true", "значение добавляемого skip-cert-verify; у отдельных прокси его\n"+
+		"можно переопределить комментарием # x509:value=false")
+	valueMapFile := fs.String("map", "", "CSV с заголовком name,skip или server,skip: значение skip-cert-verify\n"+
+		"для перечисленных прокси; остальные получают -value")
+	fs.BoolVar(&opts.IgnoreCaseKeys, "ignore-case-keys", false, "искать поле без учета регистра, дефисов и подчеркиваний:\n"+
+		"Skip-Cert-Verify, skip_cert_verify и SkipCertVerify считаются уже заданным полем\n"+
+		"(и удаляются -field-remove-if, strip-insecure); добавляется каноническая запись")
+	fs.BoolVar(&opts.UseAnchor, "use-anchor", false, "если в конфиге есть якорь, в котором только skip-cert-verify со значением\n"+
+		"-value (например, &common), подключать его к прокси ключом <<: *common\n"+
+		"вместо добавления поля")
+	fs.StringVar(&opts.ProxiesKey, "proxies-key", "proxies", "ключ верхнего уровня со списком прокси (например, all-proxies)")
+	fs.StringVar(&opts.InputFormat, "input-format", "yaml", "формат входного файла: yaml; markdown — YAML из первого блока ```yaml;\n"+
+		"auto — markdown для файлов .md и .markdown")
+	fs.BoolVar(&opts.Markdown, "markdown", false, "исправить конфиги во всех блоках ```yaml файлов .md и .markdown (как\n"+
+		"-input-format auto, но не только в первом блоке); текст и блоки на других языках не меняются")
+	fs.StringVar(&opts.OutputFormat, "output-format", "", "для markdown: markdown — документ с обновленным блоком (по умолчанию),\n"+
+		"yaml — только YAML из блока")
+	fs.BoolVar(&opts.Subconverter, "subconverter", false, "совместимость с subconverter: ключи 'Proxy:' и 'Proxy Group:'\n"+
+		"считаются равнозначными 'proxies:' и 'proxy-groups:'")
+	fs.StringVar(&opts.Report, "report", "", "записать отчет по каждому прокси (имя, сервер, тип, статус) в файл;\n"+
+		"формат по расширению: .json или .csv")
+	fs.BoolVar(&opts.ReportChanged, "report-changed-only", false, "включать в отчет -report только измененные прокси\n"+
+		"(итоги в JSON по-прежнему считаются по всем)")
+	fs.BoolVar(&opts.Deterministic, "deterministic", false, "воспроизводимый результат для проверки контрольных сумм в CI: вместо текущего\n"+
+		"времени 1980-01-01 в generated_at отчета -report, в файлах архива -bundle и в именах\n"+
+		"файлов -report-diff в папке. Журнал -stats-file по-прежнему пишет время запуска")
+	fs.BoolVar(&opts.Quiet, "quiet", false, "не выводить сообщения (ошибки по-прежнему пишутся в stderr)")
+	fs.BoolVar(&opts.ConfirmOverwrite, "confirm-overwrite", false, "спрашивать перед перезаписью существующего результата и резервной копии;\n"+
+		"без терминала и с -quiet ответ — «нет»")
+	fs.BoolVar(&opts.Force, "force", false, "перезаписывать существующие файлы без вопроса (отменяет -confirm-overwrite)\n"+
+		"и обрабатывать все файлы, даже не изменившиеся по -state")
+	yesUsage := "отвечать «да» на все вопросы (-confirm-overwrite) и не ждать Enter в конце:\n" +
+		"для скриптов. Запуск с -yes означает согласие с тем, что проверка сертификатов\n" +
+		"у прокси будет отключена"
+	fs.BoolVar(&opts.Yes, "yes", false, yesUsage)
+	fs.BoolVar(&opts.Yes, "y", false, "то же, что -yes")
+	fs.BoolVar(&opts.KeepOriginal, "keep-original-on-error", false, "с -in-place: не записывать результат, если он не разбирается как YAML\n"+
+		"или не создана резервная копия; при любой ошибке исходный файл остается\n"+
+		"байт в байт прежним, выводится путь к резервной копии")
+	fs.BoolVar(&opts.InPlace, "in-place", false, "записать результат в исходный файл (резервная копия создается как обычно)")
+	fs.BoolVar(&opts.NoFollowSymlinks, "no-follow-symlinks", false, "не обрабатывать файлы-символические ссылки; по умолчанию ссылка\n"+
+		"сохраняется, а изменяется файл, на который она указывает")
+	chmod := fs.String("chmod", "", "права для результатов и резервных копий в восьмеричном виде (например, 0600)")
+	permsFrom := fs.String("out-permissions-from", "", "взять права для результатов и резервных копий у файла-образца;\n"+
+		"явный -chmod имеет приоритет")
+	locale := fs.String("locale", "", "язык для форматирования чисел в статистике, например en (12,345)\n"+
+		"или ru (12 345); по умолчанию числа без разделителей")
+	fs.IntVar(&opts.MinModified, "min-modified", 0, "завершиться с кодом 1, если изменено меньше N прокси\n"+
+		"(защита от регрессий, когда прокси перестали находиться)")
+	fs.IntVar(&opts.MaxModified, "max-modified", -1, "завершиться с кодом 1, если изменено больше N прокси\n"+
+		"(защита от неожиданно массовых изменений); -1 — без ограничения")
+	fs.BoolVar(&opts.NoOpExitZero, "no-op-exit-zero", false, "если прокси не найдены, завершиться с кодом 0, даже при -min-modified;\n"+
+		"в пакетном режиме — если прокси нет ни в одном файле")
+
+	fs.StringVar(&opts.Dir, "dir", "", "пакетный режим: обработать все *.yaml и *.yml в папке\n"+
+		"(результат — <имя>.fixed.yaml рядом с исходным файлом)")
+	fs.BoolVar(&opts.Recursive, "recursive", false, "пакетный режим: обходить также вложенные папки")
+	fs.IntVar(&opts.MaxDepth, "max-depth", -1, "пакетный режим: обходить вложенные папки не глубже N уровней\n"+
+		"(0 — только сама папка -dir, включает -recursive); файлы глубже пропускаются и считаются")
+	fs.StringVar(&opts.OutDir, "out-dir", "", "пакетный режим: записывать результаты в эту папку,\n"+
+		"повторяя структуру вложенных папок исходной")
+	fs.StringVar(&opts.BackupDir, "backup-dir", "", "пакетный режим: записывать резервные копии в эту папку\n"+
+		"(по умолчанию — рядом с исходными файлами)")
+	fs.BoolVar(&opts.Passthrough, "passthrough", false, "пакетный режим: копировать в -out-dir без изменений файлы без прокси\n"+
+		"и все прочие файлы, чтобы папка результатов была полной копией исходной")
+	fs.BoolVar(&opts.SkipNoProxies, "skip-no-proxies", false, "пакетный режим: файлы без секции прокси не считать конфигами\n"+
+		"и ничего для них не записывать")
+	fs.StringVar(&opts.State, "state", "", "пакетный режим: файл с хэшами обработанных файлов; файлы, содержимое\n"+
+		"которых и параметры (-value, -placement, ...) не изменились с прошлого\n"+
+		"запуска, пропускаются")
+	fs.BoolVar(&opts.KeepGoing, "keep-going", false, "пакетный режим: при ошибке в файле продолжать с остальными\n"+
+		"(по умолчанию обработка останавливается на первой ошибке); код выхода 1, если были ошибки")
+	fs.Var((*stringList)(&opts.Inputs), "in", "обработать этот файл (можно указать несколько раз); файлы можно\n"+
+		"передать и аргументами: err_x509 a.yaml b.yaml, после -- имена могут начинаться с '-'.\n"+
+		"Шаблон (-in 'config-*.yaml') заменяется единственным подходящим файлом")
+	fs.BoolVar(&opts.Newest, "newest", false, "если под шаблон -in подходит несколько файлов, взять измененный последним")
+	fs.BoolVar(&opts.Mkdir, "mkdir", false, "создать недостающие папки для результата и резервной копии; в пакетном\n"+
+		"режиме папки внутри -out-dir и -backup-dir создаются и без него")
+	fs.StringVar(&opts.Profiles, "profiles", "", "обработать профили, перечисленные в этом индексе: YAML со списком путей\n"+
+		"(строкой или полем path) под ключом -profiles-key; пути считаются от папки индекса")
+	fs.StringVar(&opts.ProfilesKey, "profiles-key", "profiles", "ключ списка путей в индексе -profiles")
+	fs.StringVar(&opts.Field, "field", defaultPlacement, "добавляемое поле (путь из ключей через точку) для типов прокси без -placement;\n"+
+		"заменяет встроенную таблицу, например -field client-fingerprint -value chrome")
+	removeIf := fs.String("field-remove-if", "", "вместо добавления удалить поле у прокси, где key=value (например,\n"+
+		"type=ss); путь к полю — как при добавлении (-field, -placement), остальные прокси не меняются")
+	fs.StringVar(&opts.FieldType, "field-type", fieldAuto, "тип значения поля: auto — как есть, bool, string (в кавычках,\n"+
+		"если нужно для YAML), int")
+	fs.BoolVar(&opts.TrimTrailing, "trim-trailing-whitespace", false, "убрать пробелы и табуляции в конце всех строк результата;\n"+
+		"блочные скаляры (| и >) и многострочные значения в кавычках не меняются")
+	fs.StringVar(&opts.EOL, "eol", "preserve", "перевод строки в результате: preserve — как во входном файле, lf или crlf")
+	fs.StringVar(&opts.Decode, "decode", "none", "распаковка тела подписки: none — файл читается как есть,\n"+
+		"auto — снять слои base64 и gzip (например, base64 от gzip от YAML)")
+	fs.BoolVar(&opts.EmitEmptySection, "emit-empty-section", false, "если в конфиге нет секции прокси, добавить пустой список proxies: []")
+	fs.StringVar(&opts.FromCSV, "from-csv", "", "построить конфиг из CSV (заголовок: name,type,server,port и любые\n"+
+		"другие поля прокси), добавить поле ко всем прокси и записать в x509_fixed.yaml")
+	fs.BoolVar(&opts.Init, "init", false, "записать пример конфига в "+defaultInput+", чтобы было с чего начать;\n"+
+		"существующий файл перезаписывается только с -force")
+	fs.BoolVar(&opts.BatchStdin, "batch-stdin", false, "режим сервиса: читать из stdin запросы JSON lines\n"+
+		"{\"content\": \"...\", \"options\": {...}} и на каждый писать строку с результатом")
+	fs.StringVar(&opts.Compare, "compare", "", "сравнить skip-cert-verify у прокси входного конфига и этого файла:\n"+
+		"показать прокси с разным значением и прокси только в одном из конфигов;\n"+
+		"файлы не записываются, код выхода 1 — есть отличия")
+	fs.StringVar(&opts.GroupCount, "group-count", "", "вывести число прокси по значениям поля (server, type, port\n"+
+		"или путь через точку, например tls.sni) по убыванию; файлы не записываются")
+	fs.BoolVar(&opts.ListMissing, "list-missing", false, "только вывести прокси без добавляемого поля (имя, сервер, строка); файлы\n"+
+		"не изменяются, код выхода 1 — такие прокси есть")
+	fs.StringVar(&opts.ListMissingOut, "list-missing-out", "", "записать список -list-missing в этот CSV (name,type,server,line,status)")
+	fs.BoolVar(&opts.ValidateOnly, "validate-only", false, "только проверить поля прокси по таблице известных полей Clash / Mihomo\n"+
+		"(опечатки вроде severname); файлы не записываются, код выхода 1 — есть замечания")
+	fs.StringVar(&opts.CompareBy, "compare-by", "name", "как сопоставлять прокси в -compare: name — по имени, server — по server:port")
+	emoji := fs.Bool("emoji", true, "эмодзи в выводе; -emoji=false заменяет их метками [OK], [WARN], [ERR].\n"+
+		"Без флага эмодзи отключает и переменная окружения NO_COLOR")
+	fs.StringVar(&opts.EmitScript, "emit-script", "", "записать в этот файл sh-скрипт с командами yq, который повторяет\n"+
+		"добавление поля без err_x509; повторный запуск скрипта ничего не меняет")
+	fs.StringVar(&opts.StatsFile, "stats-file", "", "дописывать в этот CSV строку статистики каждого запуска\n"+
+		"(timestamp,file,added,total); файл создается с заголовком")
+	includeName := fs.String("include-name", "", "добавлять поле только прокси, имя которых совпадает с регулярным\n"+
+		"выражением (Unicode: эмодзи и иероглифы сравниваются как есть)")
+	excludeName := fs.String("exclude-name", "", "не добавлять поле прокси, имя которых совпадает с регулярным выражением")
+	includeServer := fs.String("include-server", "", "добавлять поле только прокси, server которых подходит под один из шаблонов\n"+
+		"через запятую, например *.example.com")
+	excludeServer := fs.String("exclude-server", "", "не добавлять поле прокси, server которых подходит под один из шаблонов")
+	fs.BoolVar(&opts.OnlyTypeTLS, "only-type-tls", false, "добавлять поле только прокси с TLS: trojan, hysteria2 и другие типы,\n"+
+		"которые всегда используют TLS, и vmess, vless, http, socks5 с tls: true (таблица: -list-tls-types)")
+	fs.BoolVar(&opts.ListTLSTypes, "list-tls-types", false, "вывести, какие типы прокси используют TLS (для -only-type-tls), и выйти")
+	fs.BoolVar(&opts.WarnSNIMismatch, "warn-sni-mismatch", false, "предупреждать (sni-mismatch), если у прокси с отключенной проверкой\n"+
+		"сертификата sni или servername не совпадает с server: часто это ошибка копирования")
+	fs.BoolVar(&opts.Verbose, "verbose", false, "подробный вывод: с -only-type-tls — почему каждый прокси выбран или пропущен")
+	requireFields := fs.String("require-field", "", "добавлять поле только прокси, у которых есть одно из этих полей\n"+
+		"через запятую (например sni,servername), независимо от типа")
+	var placements []string
+	fs.Var((*stringList)(&placements), "placement", "куда добавлять поле для типа прокси: type=path, путь из ключей\n"+
+		"через точку, например hysteria2=tls.insecure (можно указать несколько раз)")
+	// С flag.ExitOnError ошибку в флагах fs выводит сам и завершает работу
+	if err := parseArgs(fs, args, &opts.Inputs); err != nil {
+		fmt.Fprintf(console, "❌ %v\n", err)
+		os.Exit(2)
+	}
+	if err := expandInputs(opts); err != nil {
+		fmt.Fprintf(console, "❌ %v\n", err)
+		os.Exit(2)
+	}
+	if opts.Profiles != "" {
+		if opts.Dir != "" || len(opts.Inputs) > 0 {
+			fmt.Fprintln(console, "❌ Флаг -profiles нельзя сочетать с -dir и файлами (-in и аргументы)")
+			os.Exit(2)
+		}
+		paths, err := loadProfiles(opts.Profiles, opts.ProfilesKey)
+		if err != nil {
+			fmt.Fprintf(console, "❌ Ошибка чтения индекса -profiles %s: %v\n", opts.Profiles, err)
+			os.Exit(2)
+		}
+		opts.Inputs = paths
+	}
+
+	// Вывод меняется только для командной строки: Fix из кода его не трогает
+	if fs == flag.CommandLine && (!*emoji || (os.Getenv("NO_COLOR") != "" && !isFlagSet(fs, "emoji"))) {
+		disableEmoji()
+	}
+
+	opts.KeepFields = splitList(*keepFields)
+	opts.Transforms = splitList(*transforms)
+	if *jsonCompact {
+		if isFlagSet(fs, "json-indent") && opts.JSONIndent != 0 {
+			fmt.Fprintln(console, "❌ Флаги -json-compact и -json-indent несовместимы")
+			os.Exit(2)
+		}
+		opts.JSONIndent = 0
+	}
+	if opts.JSONIndent < 0 {
+		fmt.Fprintf(console, "❌ Неверное значение -json-indent: %d (ожидается N >= 0)\n", opts.JSONIndent)
+		os.Exit(2)
+	}
+	if strings.ContainsAny(opts.FieldComment, "\r\n") {
+		fmt.Fprintln(console, "❌ -field-comment: комментарий должен быть в одну строку")
+		os.Exit(2)
+	}
+	opts.RequireFields = splitList(*requireFields)
+	for _, key := range opts.RequireFields {
+		if strings.Contains(key, ".") {
+			fmt.Fprintf(console, "❌ -require-field: %s — проверяются только поля верхнего уровня прокси, без вложенности\n", key)
+			os.Exit(2)
+		}
+	}
+	if *redact || isFlagSet(fs, "redact-fields") {
+		opts.Redact = splitList(*redactFields)
+	}
+	if *removeIf != "" {
+		condition, err := parseRemoveCondition(*removeIf)
+		if err != nil {
+			fmt.Fprintf(console, "❌ %v\n", err)
+			os.Exit(2)
+		}
+		opts.RemoveIf = condition
+	}
+	switch opts.ReconcileSNI {
+	case "":
+	case reconcileBoth, reconcileToSNI, reconcileToServername:
+		if !opts.reconcilesSNI() {
+			opts.Transforms = append([]string{reconcileSNIStep}, opts.transforms()...)
+		}
+	default:
+		fmt.Fprintf(console, "❌ Неверное значение -reconcile-sni: %s (допустимо: both, sni, servername)\n", opts.ReconcileSNI)
+		os.Exit(2)
+	}
+	if err := checkTransforms(opts.Transforms); err != nil {
+		fmt.Fprintf(console, "❌ -transform: %v\n", err)
+		os.Exit(2)
+	}
+	placementTable, err := parsePlacements(placements)
+	if err != nil {
+		fmt.Fprintf(console, "❌ %v\n", err)
+		os.Exit(2)
+	}
+	opts.Placements = placementTable
+	opts.IncludeName = compilePattern("include-name", *includeName)
+	opts.ExcludeName = compilePattern("exclude-name", *excludeName)
+	for name, value := range map[string]string{"include-server": *includeServer, "exclude-server": *excludeServer} {
+		globs, err := parseServerGlobs(name, value)
+		if err != nil {
+			fmt.Fprintf(console, "❌ %v\n", err)
+			os.Exit(2)
+		}
+		if name == "include-server" {
+			opts.IncludeServer = globs
+		} else {
+			opts.ExcludeServer = globs
+		}
+	}
+	if *denyServers != "" {
+		list, err := loadServerList(*denyServers)
+		if err != nil {
+			fmt.Fprintf(console, "❌ Ошибка чтения списка -deny-servers: %v\n", err)
+			os.Exit(2)
+		}
+		opts.DenyServers = list
+	}
+
+	if *valueMapFile != "" {
+		m, err := loadValueMap(*valueMapFile)
+		if err != nil {
+			fmt.Fprintf(console, "❌ Ошибка чтения -map: %v\n", err)
+			os.Exit(2)
+		}
+		opts.ValueMap = m
+	}
+	if *errorLog != "" {
+		list, err := loadErrorLog(*errorLog)
+		if err != nil {
+			fmt.Fprintf(console, "❌ Ошибка чтения журнала -error-log: %v\n", err)
+			os.Exit(2)
+		}
+		opts.ErrorHosts = list
+	}
+
+	if *locale != "" {
+		tag, err := language.Parse(*locale)
+		if err != nil {
+			fmt.Fprintf(console, "❌ Неизвестный язык -locale: %s\n", *locale)
+			os.Exit(2)
+		}
+		printer = message.NewPrinter(tag)
+	}
+
+	// Явный -chmod важнее образца -out-permissions-from
+	if *chmod != "" {
+		mode, err := strconv.ParseUint(*chmod, 8, 32)
+		if err != nil || mode > 0777 {
+			fmt.Fprintf(console, "❌ Неверное значение -chmod: %s (ожидаются права вида 0644)\n", *chmod)
+			os.Exit(2)
+		}
+		opts.FileMode = os.FileMode(mode)
+	} else if *permsFrom != "" {
+		info, err := os.Stat(*permsFrom)
+		if err != nil {
+			fmt.Fprintf(console, "❌ Ошибка чтения образца -out-permissions-from: %v\n", err)
+			os.Exit(2)
+		}
+		opts.FileMode = info.Mode().Perm()
+	}
+
+	if opts.BackupFormat != "copy" && opts.BackupFormat != "patch" {
+		fmt.Fprintf(console, "❌ Неизвестный формат резервной копии: %s (допустимо: copy, patch)\n", opts.BackupFormat)
+		os.Exit(2)
+	}
+	if opts.BackupMode != backupOriginal && opts.BackupMode != backupPreviousOutput {
+		fmt.Fprintf(console, "❌ Неизвестный режим резервной копии: %s (допустимо: %s, %s)\n",
+			opts.BackupMode, backupOriginal, backupPreviousOutput)
+		os.Exit(2)
+	}
+	if opts.BackupMode == backupPreviousOutput && opts.BackupFormat == "patch" {
+		fmt.Fprintln(console, "❌ -backup-mode previous-output работает только с -backup-format copy")
+		os.Exit(2)
+	}
+	if opts.Value = strings.TrimSpace(opts.Value); opts.Value == "" {
+		fmt.Fprintln(console, "❌ Значение -value не может быть пустым")
+		os.Exit(2)
+	}
+	if value, err := checkFieldValue(opts.Value, opts.FieldType); err != nil {
+		fmt.Fprintf(console, "❌ -value и -field-type: %v\n", err)
+		os.Exit(2)
+	} else {
+		opts.Value = value
+	}
+	if err := checkPath(opts.Field); err != nil {
+		fmt.Fprintf(console, "❌ -field: %v\n", err)
+		os.Exit(2)
+	}
+	if err := checkFlavor(opts); err != nil {
+		fmt.Fprintf(console, "❌ %v\n", err)
+		os.Exit(2)
+	}
+	if opts.ProxiesKey = strings.TrimSpace(opts.ProxiesKey); opts.ProxiesKey == "" {
+		fmt.Fprintln(console, "❌ Значение -proxies-key не может быть пустым")
+		os.Exit(2)
+	}
+	if opts.MaxModified >= 0 && opts.MaxModified < opts.MinModified {
+		fmt.Fprintf(console, "❌ -max-modified (%d) меньше -min-modified (%d)\n", opts.MaxModified, opts.MinModified)
+		os.Exit(2)
+	}
+	switch opts.InputFormat {
+	case "yaml", "markdown", "auto":
+	default:
+		fmt.Fprintf(console, "❌ Неизвестный формат -input-format: %s (допустимо: yaml, markdown, auto)\n", opts.InputFormat)
+		os.Exit(2)
+	}
+	if opts.Markdown {
+		if opts.OutputFormat == "yaml" {
+			fmt.Fprintln(console, "❌ С -markdown нельзя -output-format yaml: блоков ```yaml может быть несколько")
+			os.Exit(2)
+		}
+		if opts.InputFormat == "yaml" {
+			opts.InputFormat = "auto"
+		}
+	}
+	switch opts.OutputFormat {
+	case "", "markdown", "yaml":
+	default:
+		fmt.Fprintf(console, "❌ Неизвестный формат -output-format: %s (допустимо: markdown, yaml)\n", opts.OutputFormat)
+		os.Exit(2)
+	}
+	if opts.Context < 0 {
+		fmt.Fprintln(console, "❌ Значение -context не может быть отрицательным")
+		os.Exit(2)
+	}
+	if opts.Report != "" {
+		if _, err := reportFormat(opts.Report); err != nil {
+			fmt.Fprintf(console, "❌ %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if opts.AliasList != aliasListAnchor && opts.AliasList != aliasListInline {
+		fmt.Fprintf(console, "❌ Неизвестный способ -alias-list: %s (допустимо: %s, %s)\n", opts.AliasList, aliasListAnchor, aliasListInline)
+		os.Exit(2)
+	}
+	if opts.DedupKeys != "" && opts.DedupKeys != dedupKeysLast && opts.DedupKeys != dedupKeysFirst {
+		fmt.Fprintf(console, "❌ Неизвестное значение -dedup-keys: %s (допустимо: %s, %s)\n", opts.DedupKeys, dedupKeysLast, dedupKeysFirst)
+		os.Exit(2)
+	}
+	if opts.DedupBy != "" && opts.DedupBy != dedupByName {
+		fmt.Fprintf(console, "❌ Неизвестный способ -dedup-by: %s (допустимо: %s)\n", opts.DedupBy, dedupByName)
+		os.Exit(2)
+	}
+	if opts.ReportChanged && opts.Report == "" {
+		fmt.Fprintln(console, "❌ Флаг -report-changed-only работает только вместе с -report")
+		os.Exit(2)
+	}
+	if opts.Dir != "" && len(opts.Inputs) > 0 {
+		fmt.Fprintln(console, "❌ Укажите либо папку -dir, либо файлы (-in и аргументы), но не то и другое")
+		os.Exit(2)
+	}
+	if opts.Dir == "" && (opts.Recursive || opts.Passthrough || opts.MaxDepth >= 0) {
+		fmt.Fprintln(console, "❌ Флаги -recursive, -max-depth и -passthrough работают только вместе с -dir")
+		os.Exit(2)
+	}
+	if opts.MaxDepth < -1 {
+		fmt.Fprintf(console, "❌ Неверное значение -max-depth: %d (ожидается N >= 0)\n", opts.MaxDepth)
+		os.Exit(2)
+	}
+	if opts.MaxDepth >= 0 {
+		opts.Recursive = true
+	}
+	if !opts.batch() && (opts.OutDir != "" || opts.BackupDir != "" || opts.SkipNoProxies || opts.State != "" || opts.KeepGoing) {
+		fmt.Fprintln(console, "❌ Флаги -out-dir, -backup-dir, -skip-no-proxies, -state и -keep-going работают только вместе с -dir или списком файлов")
+		os.Exit(2)
+	}
+	if opts.Passthrough && opts.SkipNoProxies {
+		fmt.Fprintln(console, "❌ Флаги -passthrough и -skip-no-proxies несовместимы")
+		os.Exit(2)
+	}
+	if opts.EmitEmptySection && opts.SkipNoProxies {
+		fmt.Fprintln(console, "❌ Флаги -emit-empty-section и -skip-no-proxies несовместимы")
+		os.Exit(2)
+	}
+	if opts.DryRunOut != "" {
+		if opts.Dir != "" || len(opts.Inputs) > 1 || opts.BatchStdin {
+			fmt.Fprintln(console, "❌ С -dry-run-out можно указать только один входной файл, без -dir и -batch-stdin")
+			os.Exit(2)
+		}
+		opts.DryRun = true
+	}
+	if opts.PostHook != "" {
+		if _, err := splitCommand(opts.PostHook); err != nil {
+			fmt.Fprintf(console, "❌ -post-hook: %v\n", err)
+			os.Exit(2)
+		}
+	} else if opts.HookStrict {
+		fmt.Fprintln(console, "❌ Флаг -hook-strict работает только вместе с -post-hook")
+		os.Exit(2)
+	}
+	if opts.ReportDiffSplit && opts.ReportDiff == "" {
+		fmt.Fprintln(console, "❌ Флаг -report-diff-split работает только вместе с -report-diff")
+		os.Exit(2)
+	}
+	if (opts.ReportDiff != "" || opts.Bundle != "") && (opts.BatchStdin || opts.FromCSV != "") {
+		fmt.Fprintln(console, "❌ -report-diff и -bundle нельзя указывать с -batch-stdin и -from-csv")
+		os.Exit(2)
+	}
+	if opts.Webhook != "" {
+		if err := checkWebhook(opts); err != nil {
+			fmt.Fprintf(console, "❌ %v\n", err)
+			os.Exit(2)
+		}
+	} else if opts.WebhookStrict || opts.WebhookHeader != "" {
+		fmt.Fprintln(console, "❌ Флаги -webhook-strict и -webhook-header работают только вместе с -summary-webhook")
+		os.Exit(2)
+	}
+	if opts.KeepOriginal && !opts.InPlace {
+		fmt.Fprintln(console, "❌ Флаг -keep-original-on-error работает только вместе с -in-place")
+		os.Exit(2)
+	}
+	if opts.InPlace && opts.OutDir != "" {
+		fmt.Fprintln(console, "❌ Флаги -in-place и -out-dir несовместимы")
+		os.Exit(2)
+	}
+	if opts.Decode != "none" && opts.Decode != "auto" {
+		fmt.Fprintf(console, "❌ Неверное значение -decode: %s (допустимо: none, auto)\n", opts.Decode)
+		os.Exit(2)
+	}
+	if opts.Document < 0 {
+		fmt.Fprintf(console, "❌ Неверное значение -document: %d (допустимо: 0 или номер документа с 1)\n", opts.Document)
+		os.Exit(2)
+	}
+	if opts.Wrap < 0 {
+		fmt.Fprintf(console, "❌ Неверное значение -wrap: %d (допустимо: 0 или больше)\n", opts.Wrap)
+		os.Exit(2)
+	}
+	if opts.EOL != "preserve" && opts.EOL != "lf" && opts.EOL != "crlf" {
+		fmt.Fprintf(console, "❌ Неверное значение -eol: %s (допустимо: preserve, lf, crlf)\n", opts.EOL)
+		os.Exit(2)
+	}
+	if opts.CompareBy != "name" && opts.CompareBy != "server" {
+		fmt.Fprintf(console, "❌ Неверное значение -compare-by: %s (допустимо: name, server)\n", opts.CompareBy)
+		os.Exit(2)
+	}
+	if opts.BatchStdin && (opts.batch() || opts.Compare != "" || opts.FromCSV != "") {
+		fmt.Fprintln(console, "❌ С -batch-stdin нельзя указывать входные файлы, -dir, -compare и -from-csv")
+		os.Exit(2)
+	}
+	if opts.Init && (opts.batch() || opts.Compare != "" || opts.FromCSV != "" || opts.BatchStdin || opts.ValidateOnly ||
+		opts.GroupCount != "" || opts.ListMissing || opts.ListMissingOut != "") {
+		fmt.Fprintln(console, "❌ С -init нельзя указывать входные файлы, -dir и другие режимы")
+		os.Exit(2)
+	}
+	if opts.FromCSV != "" && (opts.batch() || opts.Compare != "") {
+		fmt.Fprintln(console, "❌ С -from-csv нельзя указывать входные файлы, -dir и -compare")
+		os.Exit(2)
+	}
+	if opts.GroupCount != "" {
+		if err := checkPath(opts.GroupCount); err != nil {
+			fmt.Fprintf(console, "❌ -group-count: %v\n", err)
+			os.Exit(2)
+		}
+		if opts.Dir != "" || len(opts.Inputs) > 1 || opts.Compare != "" || opts.FromCSV != "" || opts.BatchStdin || opts.ValidateOnly {
+			fmt.Fprintln(console, "❌ С -group-count можно указать только один входной файл, без -compare, -from-csv, -batch-stdin и -validate-only")
+			os.Exit(2)
+		}
+	}
+	if opts.ValidateOnly && (opts.Dir != "" || len(opts.Inputs) > 1 || opts.Compare != "" || opts.FromCSV != "" || opts.BatchStdin) {
+		fmt.Fprintln(console, "❌ С -validate-only можно указать только один входной файл, без -compare, -from-csv и -batch-stdin")
+		os.Exit(2)
+	}
+	if opts.ListMissingOut != "" {
+		opts.ListMissing = true
+	}
+	if opts.ListMissing && (opts.Dir != "" || len(opts.Inputs) > 1 || opts.Compare != "" || opts.FromCSV != "" ||
+		opts.BatchStdin || opts.ValidateOnly || opts.GroupCount != "" || opts.RemoveIf != nil) {
+		fmt.Fprintln(console, "❌ С -list-missing можно указать только один входной файл, без -compare, -from-csv, -batch-stdin,\n"+
+			"   -validate-only, -group-count и -field-remove-if")
+		os.Exit(2)
+	}
+	if opts.Compare != "" && (opts.Dir != "" || len(opts.Inputs) > 1) {
+		fmt.Fprintln(console, "❌ С -compare можно указать только один входной файл")
+		os.Exit(2)
+	}
+	if opts.Passthrough && opts.OutDir == "" {
+		fmt.Fprintln(console, "❌ Флаг -passthrough работает только вместе с -out-dir")
+		os.Exit(2)
+	}
+	return opts
+}
+
+// backupPrevious сообщает, что резервная копия хранит прежний результат
+// (-backup-mode previous-output). С -in-place прежний результат и есть
+// исходный файл, поэтому копия пишется как обычно.
+func (o *options) backupPrevious() bool {
+	return o.BackupMode == backupPreviousOutput && !o.InPlace
+}
+
+// batch проверяет, что обрабатывается папка или список файлов,
+// а не стандартный x509_no_fix.yaml
+func (o *options) batch() bool {
+	return o.Dir != "" || len(o.Inputs) > 0
+}
+
+// compilePattern компилирует регулярное выражение из флага; пустое — nil
+func compilePattern(name, pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Fprintf(console, "❌ Неверное регулярное выражение -%s: %v\n", name, err)
+		os.Exit(2)
+	}
+	return re
+}
+
+// nameFilter сообщает, что задан -include-name или -exclude-name
+func (o *options) nameFilter() bool {
+	return o.IncludeName != nil || o.ExcludeName != nil
+}
+
+// nameSelected проверяет имя прокси по -include-name и -exclude-name.
+// Регулярные выражения Go работают с UTF-8, поэтому эмодзи и иероглифы
+// в имени и в выражении сравниваются без преобразований.
+func (o *options) nameSelected(name string) bool {
+	if o.IncludeName != nil && !o.IncludeName.MatchString(name) {
+		return false
+	}
+	return o.ExcludeName == nil || !o.ExcludeName.MatchString(name)
+}
+
+// isFlagSet проверяет, что флаг указан в командной строке
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// stringList — флаг, который можно указать несколько раз
+type stringList []string
+
+// parseArgs разбирает флаги args и добавляет позиционные аргументы (файлы)
+// в inputs вперемешку с -in, в порядке командной строки. Пакет flag
+// останавливается на первом позиционном аргументе, поэтому после него разбор
+// продолжается: "a.yaml -in b.yaml" — два файла, а не файл с именем -in.
+// После "--" все аргументы — файлы, и имя может начинаться с '-'.
+func parseArgs(fs *flag.FlagSet, args []string, inputs *[]string) error {
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			*inputs = append(*inputs, rest...)
+			return nil
+		}
+		for len(rest) > 0 && (rest[0] == "-" || !strings.HasPrefix(rest[0], "-")) {
+			*inputs = append(*inputs, rest[0])
+			rest = rest[1:]
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+		args = rest
+	}
+}
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// splitList разбивает список значений через запятую, отбрасывая пустые
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// transforms возвращает шаги обработки; без -transform — только add-skip-cert
+func (o *options) transforms() []string {
+	if len(o.Transforms) == 0 {
+		return []string{addSkipCert}
+	}
+	return o.Transforms
+}
+
+// deterministicTime — время с -deterministic: самая ранняя дата, которую
+// хранит zip
+var deterministicTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// now возвращает время для generated_at отчета, файлов в архиве -bundle
+// и имен -report-diff в папке; с -deterministic — всегда одно и то же
+func (o *options) now() time.Time {
+	if o.Deterministic {
+		return deterministicTime
+	}
+	return time.Now()
+}
+
+// proxiesKeys возвращает ключи верхнего уровня, под которыми лежит список прокси
+func (o *options) proxiesKeys() []string {
+	if o.Subconverter {
+		return []string{o.ProxiesKey, "Proxy"}
+	}
+	return []string{o.ProxiesKey}
+}
+
+// groupsKeys возвращает ключи верхнего уровня, под которыми лежат группы прокси
+func (o *options) groupsKeys() []string {
+	if o.Subconverter {
+		return []string{"proxy-groups", "Proxy Group"}
+	}
+	return []string{"proxy-groups"}
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// testOptions разбирает args так же, как parseFlags разбирает командную
+// строку, на свежем наборе флагов: тесты получают те же значения
+// по умолчанию и проверки, что и запуск
+func testOptions(t *testing.T, args ...string) *options {
+	t.Helper()
+	savedArgs, savedFlags := os.Args, flag.CommandLine
+	t.Cleanup(func() { os.Args, flag.CommandLine = savedArgs, savedFlags })
+	os.Args = append([]string{"err_x509"}, args...)
+	flag.CommandLine = flag.NewFlagSet("err_x509", flag.ExitOnError)
+	return parseFlags()
+}
+
+func TestParseArgs(t *testing.T) {
+	cases := []struct {
+		args   []string
+		inputs []string
+		dryRun bool
+	}{
+		{[]string{"a.yaml", "b.yaml"}, []string{"a.yaml", "b.yaml"}, false},
+		{[]string{"-in", "a.yaml", "b.yaml"}, []string{"a.yaml", "b.yaml"}, false},
+		{[]string{"a.yaml", "-in", "b.yaml"}, []string{"a.yaml", "b.yaml"}, false},
+		{[]string{"a.yaml", "-dry-run", "b.yaml", "-in", "c.yaml"}, []string{"a.yaml", "b.yaml", "c.yaml"}, true},
+		{[]string{"--", "-weird.yaml"}, []string{"-weird.yaml"}, false},
+		{[]string{"-dry-run", "--", "-weird.yaml", "-in"}, []string{"-weird.yaml", "-in"}, true},
+		{[]string{"a.yaml", "--", "-weird.yaml", "-dry-run"}, []string{"a.yaml", "-weird.yaml", "-dry-run"}, false},
+		{[]string{"a.yaml", "--"}, []string{"a.yaml"}, false},
+		{[]string{"-"}, []string{"-"}, false},
+	}
+	for _, c := range cases {
+		var inputs []string
+		fs := flag.NewFlagSet("err_x509", flag.ContinueOnError)
+		fs.Var((*stringList)(&inputs), "in", "")
+		dryRun := fs.Bool("dry-run", false, "")
+		if err := parseArgs(fs, c.args, &inputs); err != nil {
+			t.Errorf("%q: %v", c.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(inputs, c.inputs) || *dryRun != c.dryRun {
+			t.Errorf("%q: файлы %q, -dry-run %v; want %q, %v", c.args, inputs, *dryRun, c.inputs, c.dryRun)
+		}
+	}
+
+	fs := flag.NewFlagSet("err_x509", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var inputs []string
+	if err := parseArgs(fs, []string{"a.yaml", "-no-such-flag"}, &inputs); err == nil {
+		t.Errorf("неизвестный флаг после файла не вызвал ошибку, файлы %q", inputs)
+	}
+}
+
+// TestWeirdNameAfterDashDash проверяет, что файл testdata/-weird.yaml
+// после -- попадает в список файлов и обрабатывается
+func TestWeirdNameAfterDashDash(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("testdata"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(dir)
+
+	opts := testOptions(t, "-dry-run", "--", "-weird.yaml")
+	if !reflect.DeepEqual(opts.Inputs, []string{"-weird.yaml"}) || !opts.DryRun {
+		t.Fatalf("файлы %q, -dry-run %v", opts.Inputs, opts.DryRun)
+	}
+	files, err := inputFiles(opts)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("inputFiles: %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0].Input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := processContent(string(data), opts)
+	if err != nil || res.Modified != 2 {
+		t.Errorf("-weird.yaml: добавлено %d, %v; want 2", res.Modified, err)
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	got := parseOptions(flag.NewFlagSet("errx509", flag.ContinueOnError), nil)
+	if want := defaultOptions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseOptions без флагов:\n%+v\ndefaultOptions:\n%+v", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// valueMarkerPattern — комментарий у прокси, задающий значение поля
+// вместо -value: # x509:value=false
+var valueMarkerPattern = regexp.MustCompile(`x509:value=([^\s#,]+)`)
+
+// markerValue ищет комментарий x509:value=... у записи прокси: над ней,
+// в конце ее строк или у ее полей
+func markerValue(item *yaml.Node) (string, bool) {
+	nodes := append([]*yaml.Node{item}, item.Content...)
+	for _, node := range nodes {
+		for _, comment := range []string{node.HeadComment, node.LineComment, node.FootComment} {
+			if m := valueMarkerPattern.FindStringSubmatch(comment); m != nil {
+				return m[1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// valueOverrides находит прокси с комментарием x509:value=... и возвращает
+// значения по номеру строки начала записи. Документ разбирается, только
+// если маркер вообще встречается в тексте.
+func valueOverrides(content string, keys []string) map[int]string {
+	if !strings.Contains(content, "x509:value=") {
+		return nil
+	}
+	doc, err := loadDocument(content)
+	if err != nil {
+		return nil
+	}
+	seq := proxiesNode(doc, keys)
+	if seq == nil {
+		return nil
+	}
+	overrides := map[int]string{}
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		if value, ok := markerValue(item); ok {
+			overrides[item.Line] = value
+		}
+	}
+	return overrides
+}
+
+// printMarkerValues выводит прокси, получившие значение из комментария x509:value
+func printMarkerValues(proxies []proxyStatus) {
+	for _, p := range proxies {
+		if p.FromMarker {
+			sayf("      • %s: skip-cert-verify: %s\n", p.Name, p.Value)
+		}
+	}
+}
+
+// countMarkerValues считает прокси со значением из комментария x509:value
+func countMarkerValues(proxies []proxyStatus) int {
+	return countProxies(proxies, func(p proxyStatus) bool { return p.FromMarker })
+}
+
+// countMapValues считает прокси со значением из -map
+func countMapValues(proxies []proxyStatus) int {
+	return countProxies(proxies, func(p proxyStatus) bool { return p.FromMap })
+}
+
+// countNested считает прокси, у которых проверка настроена во вложенном блоке
+func countNested(proxies []proxyStatus) int {
+	return countProxies(proxies, func(p proxyStatus) bool { return p.Nested != "" })
+}
+
+// countProxies считает прокси, для которых match возвращает true
+func countProxies(proxies []proxyStatus, match func(proxyStatus) bool) int {
+	n := 0
+	for _, p := range proxies {
+		if match(p) {
+			n++
+		}
+	}
+	return n
+}
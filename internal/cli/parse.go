@@ -0,0 +1,156 @@
+// Package cli — движок и команда err_x509 (Main, запускается из
+// cmd/err_x509): добавляет skip-cert-verify к прокси в конфигах
+// Clash / Mihomo. Для своего кода — пакет errx509 в корне модуля.
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Proxy — прокси из конфига: общие поля и все остальные в Extra.
+// ParseProxies и EncodeProxies сохраняют поля без потерь: то, что не
+// помещается в общие поля (например, порт-диапазон "443-445"), остается
+// в Extra под своим ключом.
+type Proxy struct {
+	Name   string
+	Type   string
+	Server string
+	Port   int
+	Extra  map[string]interface{}
+}
+
+// DefaultProxiesKeys — ключи секции прокси, которые ParseProxies ищет
+// по умолчанию: proxies и Proxy из конфигов subconverter. Команда без
+// флагов ищет только proxies, Proxy — с -subconverter.
+var DefaultProxiesKeys = []string{"proxies", "Proxy"}
+
+// ParseProxies разбирает конфиг и возвращает прокси в порядке следования
+// из секции под первым найденным ключом keys (без keys — DefaultProxiesKeys,
+// как -subconverter в командной строке). Файл, который сам является списком
+// прокси (proxy-provider), разбирается целиком. Ключи слияния <<
+// раскрываются: поля из якорей попадают в прокси. Конфиг без секции
+// прокси — пустой список.
+func ParseProxies(content string, keys ...string) ([]Proxy, error) {
+	if len(keys) == 0 {
+		keys = DefaultProxiesKeys
+	}
+	doc, err := loadDocument(content)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора YAML: %w", err)
+	}
+	seq := proxiesNode(doc, keys)
+	if seq == nil {
+		return nil, nil
+	}
+	proxies := make([]Proxy, 0, len(seq.Content))
+	for _, item := range seq.Content {
+		p, err := decodeProxy(item)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies, nil
+}
+
+// EncodeProxies записывает прокси как конфиг с секцией key или, если key
+// пустой, как список прокси без ключа (файл proxy-provider). Общие поля
+// идут первыми, остальные — по алфавиту; ParseProxies читает результат
+// обратно в те же значения.
+func EncodeProxies(proxies []Proxy, key string) (string, error) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, p := range proxies {
+		item := &yaml.Node{}
+		if err := item.Encode(p.Fields()); err != nil {
+			return "", fmt.Errorf("прокси %s: %w", p.Name, err)
+		}
+		sortProxyKeys(item)
+		seq.Content = append(seq.Content, item)
+	}
+	if key == "" {
+		return encodeNode(seq)
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, seq,
+	}}
+	return encodeNode(root)
+}
+
+// Fields возвращает все поля прокси одним словарем, как в конфиге.
+// Пустые общие поля не включаются.
+func (p Proxy) Fields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(p.Extra)+4)
+	for key, value := range p.Extra {
+		fields[key] = value
+	}
+	for key, value := range map[string]string{"name": p.Name, "type": p.Type, "server": p.Server} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	if p.Port != 0 {
+		fields["port"] = p.Port
+	}
+	return fields
+}
+
+// decodeProxy разбирает запись списка прокси (или ссылку на нее: - *name).
+// Общие поля переносятся из словаря, только если их значение подходит
+// по типу.
+func decodeProxy(item *yaml.Node) (Proxy, error) {
+	var fields map[string]interface{}
+	if item.Kind == yaml.AliasNode && item.Alias != nil {
+		item = item.Alias
+	}
+	if item.Kind != yaml.MappingNode {
+		return Proxy{}, fmt.Errorf("строка %d: запись прокси не является словарем", item.Line)
+	}
+	if err := item.Decode(&fields); err != nil {
+		return Proxy{}, fmt.Errorf("строка %d: %w", item.Line, err)
+	}
+	p := Proxy{Extra: fields}
+	for key, dst := range map[string]*string{"name": &p.Name, "type": &p.Type, "server": &p.Server} {
+		if value, ok := fields[key].(string); ok && value != "" {
+			*dst = value
+			delete(fields, key)
+		}
+	}
+	// Пустые значения, порт в кавычках ("443") и диапазон остаются в Extra как есть
+	if port, ok := fields["port"].(int); ok && port != 0 {
+		p.Port = port
+		delete(fields, "port")
+	}
+	return p, nil
+}
+
+// proxyKeyOrder — порядок общих полей при записи прокси
+var proxyKeyOrder = map[string]int{"name": 0, "type": 1, "server": 2, "port": 3}
+
+// sortProxyKeys ставит общие поля записи первыми, остальные — по алфавиту
+func sortProxyKeys(item *yaml.Node) {
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(item.Content)/2)
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		pairs = append(pairs, pair{item.Content[i], item.Content[i+1]})
+	}
+	rank := func(key string) int {
+		if r, ok := proxyKeyOrder[key]; ok {
+			return r
+		}
+		return len(proxyKeyOrder)
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		ri, rj := rank(pairs[i].key.Value), rank(pairs[j].key.Value)
+		if ri != rj {
+			return ri < rj
+		}
+		return pairs[i].key.Value < pairs[j].key.Value
+	})
+	item.Content = item.Content[:0]
+	for _, p := range pairs {
+		item.Content = append(item.Content, p.key, p.value)
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestParseProxiesRoundTrip проверяет, что ParseProxies находит список
+// под ключом -proxies-key, ключом subconverter и без ключа, а EncodeProxies
+// записывает его так, что повторный разбор дает те же прокси
+func TestParseProxiesRoundTrip(t *testing.T) {
+	cases := []struct {
+		file  string
+		keys  []string
+		key   string // ключ для EncodeProxies
+		count int
+		extra string // поле, которое должно остаться в Extra
+	}{
+		{"testdata/custom_key.yaml", []string{"all-proxies"}, "all-proxies", 2, "password"},
+		{"testdata/subconverter.yaml", nil, "Proxy", 2, "password"},
+		{"testdata/proxy_provider.yaml", nil, "", 2, "sni"},
+		{"testdata/anchor.yaml", nil, "proxies", 2, "udp"},
+	}
+	for _, c := range cases {
+		data, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proxies, err := ParseProxies(string(data), c.keys...)
+		if err != nil {
+			t.Fatalf("%s: %v", c.file, err)
+		}
+		if len(proxies) != c.count {
+			t.Fatalf("%s: прокси %d, want %d", c.file, len(proxies), c.count)
+		}
+		if proxies[0].Name == "" || proxies[0].Port == 0 {
+			t.Errorf("%s: общие поля не разобраны: %+v", c.file, proxies[0])
+		}
+		if _, ok := proxies[0].Extra[c.extra]; !ok {
+			t.Errorf("%s: поля %s нет в Extra: %v", c.file, c.extra, proxies[0].Extra)
+		}
+
+		encoded, err := EncodeProxies(proxies, c.key)
+		if err != nil {
+			t.Fatalf("%s: %v", c.file, err)
+		}
+		var keys []string
+		if c.key != "" {
+			keys = []string{c.key}
+		}
+		again, err := ParseProxies(encoded, keys...)
+		if err != nil {
+			t.Fatalf("%s: повторный разбор: %v\n%s", c.file, err, encoded)
+		}
+		if !reflect.DeepEqual(again, proxies) {
+			t.Errorf("%s: после EncodeProxies прокси изменились:\n%+v\nwant\n%+v", c.file, again, proxies)
+		}
+	}
+}
+
+// TestParseProxiesKeepsUnknownFields проверяет поля, которые не подходят
+// к общим по типу: они остаются в Extra как есть
+func TestParseProxiesKeepsUnknownFields(t *testing.T) {
+	content := `x-shared: &shared
+  name: Shared
+  type: trojan
+  port: 443
+proxies:
+  - name: Range
+    type: hysteria2
+    server: h.example.com
+    port: "443-445"
+    x-custom: {a: [1, 2]}
+  - *shared
+`
+	proxies, err := ParseProxies(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Proxy{
+		{Name: "Range", Type: "hysteria2", Server: "h.example.com", Extra: map[string]interface{}{
+			"port":     "443-445",
+			"x-custom": map[string]interface{}{"a": []interface{}{1, 2}},
+		}},
+		{Name: "Shared", Type: "trojan", Port: 443, Extra: map[string]interface{}{}},
+	}
+	if !reflect.DeepEqual(proxies, want) {
+		t.Errorf("ParseProxies = %+v, want %+v", proxies, want)
+	}
+	encoded, err := EncodeProxies(proxies, "proxies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseProxies(encoded)
+	if err != nil || !reflect.DeepEqual(again, want) {
+		t.Errorf("после EncodeProxies = %+v, %v, want %+v\n%s", again, err, want, encoded)
+	}
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultPlacement — поле для типов, которых нет в таблице
+const defaultPlacement = "skip-cert-verify"
+
+// parsePlacements разбирает значения -placement вида type=path
+func parsePlacements(values []string) (map[string]string, error) {
+	placements := map[string]string{}
+	for _, value := range values {
+		proxyType, path, ok := strings.Cut(value, "=")
+		proxyType, path = strings.TrimSpace(proxyType), strings.TrimSpace(path)
+		if !ok || proxyType == "" || path == "" {
+			return nil, fmt.Errorf("неверное значение -placement %q, ожидается type=path", value)
+		}
+		if err := checkPath(path); err != nil {
+			return nil, fmt.Errorf("-placement %s: %w", value, err)
+		}
+		placements[proxyType] = path
+	}
+	return placements, nil
+}
+
+// checkPath проверяет путь к полю: ключи через точку, без пустых
+func checkPath(path string) error {
+	for _, key := range strings.Split(path, ".") {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("неверный путь %q: пустой ключ", path)
+		}
+	}
+	return nil
+}
+
+// placement возвращает путь к полю для типа прокси: -placement,
+// затем -field, если задан, затем встроенная таблица и skip-cert-verify
+func (o *options) placement(proxyType string) string {
+	if path, ok := o.Placements[proxyType]; ok {
+		return path
+	}
+	if o.Field != "" && o.Field != defaultPlacement {
+		return o.Field
+	}
+	if path := proxyTypes[proxyType].Placement; path != "" {
+		return path
+	}
+	return defaultPlacement
+}
+
+// nestedPlacement сообщает, что хотя бы одно поле добавляется во вложенный
+// блок: такие изменения вносятся через разбор YAML
+func (o *options) nestedPlacement() bool {
+	if strings.Contains(o.Field, ".") {
+		return true
+	}
+	for _, path := range o.Placements {
+		if strings.Contains(path, ".") {
+			return true
+		}
+	}
+	for _, t := range proxyTypes {
+		if strings.Contains(t.Placement, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// placementNode возвращает значение по пути path в записи прокси;
+// с fold ключи сравниваются без учета регистра (-ignore-case-keys)
+func placementNode(item *yaml.Node, path string, fold bool) *yaml.Node {
+	node := item
+	for _, key := range strings.Split(path, ".") {
+		if node = foldedValue(node, key, fold); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// setPlacement добавляет поле по пути path, создавая недостающие блоки.
+// Возвращает false, если на пути уже есть значение, которое не является
+// блоком (например, tls: true для пути tls.insecure).
+func setPlacement(item *yaml.Node, path string, value *yaml.Node) bool {
+	keys := strings.Split(path, ".")
+	node := item
+	for _, key := range keys[:len(keys)-1] {
+		next := mappingValue(node, key)
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if node.Style&yaml.FlowStyle != 0 {
+				next.Style = yaml.FlowStyle
+			}
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, next)
+		} else if next.Kind != yaml.MappingNode {
+			return false
+		}
+		node = next
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: keys[len(keys)-1]}, value)
+	return true
+}
+
+// nestedVerifyPaths — вложенные поля, которыми прокси (например, VLESS с
+// reality-opts) уже настраивают проверку сертификата. Если одно из них есть,
+// поле на верхнем уровне не добавляется: оно дублировало бы настройку
+// или противоречило ей.
+var nestedVerifyPaths = []string{
+	"tls.skip-cert-verify",
+	"tls.insecure",
+	"reality-opts.skip-cert-verify",
+	"reality-opts.insecure",
+}
+
+// nestedVerify возвращает вложенное поле проверки сертификата в записи
+func nestedVerify(item *yaml.Node) (string, bool) {
+	for _, path := range nestedVerifyPaths {
+		if placementNode(item, path, false) != nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// nestedVerifyLines находит прокси с вложенным полем проверки сертификата
+// и возвращает путь к нему по номеру строки начала записи. Документ
+// разбирается, только если в тексте есть блок tls или reality-opts.
+func nestedVerifyLines(content string, keys []string) map[int]string {
+	if !strings.Contains(content, "tls:") && !strings.Contains(content, "reality-opts:") {
+		return nil
+	}
+	doc, err := loadDocument(content)
+	if err != nil {
+		return nil
+	}
+	seq := proxiesNode(doc, keys)
+	if seq == nil {
+		return nil
+	}
+	lines := map[int]string{}
+	for _, item := range seq.Content {
+		if path, ok := nestedVerify(item); ok {
+			lines[item.Line] = path
+		}
+	}
+	return lines
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+)
+
+// printChangedProxies выводит только измененные прокси (имя, до и после),
+// упорядоченные по имени, чтобы вывод был воспроизводимым. С -redact
+// значения секретных полей скрыты.
+func printChangedProxies(opts *options, changes []recordChange) {
+	sorted := make([]recordChange, len(changes))
+	copy(sorted, changes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	for _, c := range sorted {
+		sayf("• %s (строка %d)\n", c.Name, c.Line)
+		say("  ДО:    " + compactLines(opts.redactText(c.Before)))
+		say("  ПОСЛЕ: " + compactLines(opts.redactText(c.After)))
+	}
+}
+
+// compactLines склеивает многострочную запись в одну строку для вывода
+func compactLines(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, " ")
+}
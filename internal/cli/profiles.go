@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadProfiles читает индекс профилей (-profiles): YAML со списком путей
+// под ключом key. Элемент списка — путь строкой или словарь с полем path
+// (или file). Относительные пути считаются от папки индекса; повторы
+// пропускаются.
+func loadProfiles(index, key string) ([]string, error) {
+	data, err := os.ReadFile(index)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("ошибка разбора YAML: %w", err)
+	}
+	seq := sectionNode(&doc, []string{key})
+	if seq == nil {
+		return nil, fmt.Errorf("нет списка %s", key)
+	}
+
+	dir := filepath.Dir(index)
+	seen := map[string]bool{}
+	var paths []string
+	for _, item := range seq.Content {
+		path := ""
+		switch item.Kind {
+		case yaml.ScalarNode:
+			path = item.Value
+		case yaml.MappingNode:
+			if path = scalarValue(item, "path"); path == "" {
+				path = scalarValue(item, "file")
+			}
+		}
+		if path = strings.TrimSpace(path); path == "" {
+			return nil, fmt.Errorf("строка %d: элемент %s не содержит пути к профилю", item.Line, key)
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("список %s пуст", key)
+	}
+	return paths, nil
+}
+
+// profileRoot возвращает папку и относительный путь профиля для вывода
+// и для -out-dir и -backup-dir: от папки индекса, а для профиля вне
+// нее — от его собственной папки
+func profileRoot(index, path string) (string, string) {
+	root := filepath.Dir(index)
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Dir(path), filepath.Base(path)
+	}
+	return root, rel
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// fieldPatterns — кэш скомпилированных выражений для fieldValue,
+// keyPatterns — для hasField. sync.Map: Fix можно вызывать из нескольких
+// горутин сразу.
+var (
+	fieldPatterns sync.Map // ключ → *regexp.Regexp
+	keyPatterns   sync.Map
+)
+
+// cachedPattern возвращает выражение из кэша cache или компилирует его
+func cachedPattern(cache *sync.Map, key string, compile func() *regexp.Regexp) *regexp.Regexp {
+	if re, ok := cache.Load(key); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, _ := cache.LoadOrStore(key, compile())
+	return re.(*regexp.Regexp)
+}
+
+// hasField проверяет, что в записи прокси есть ключ key целиком: перед ним
+// начало текста, пробел, '{' или ',', после двоеточия — пробел, ',' или '}'.
+// Так "port:" не находится в "supported-port:", а "name:" — в "username:".
+func hasField(proxy, key string) bool {
+	re := cachedPattern(&keyPatterns, key, func() *regexp.Regexp {
+		return regexp.MustCompile(`(?:^|[\s{,])` + regexp.QuoteMeta(key) + `:(?:[\s,}]|$)`)
+	})
+	return re.MatchString(proxy)
+}
+
+// blankComments заменяет комментарии в тексте записи пробелами той же
+// длины: ключи из "# skip-cert-verify: false" или из комментария внутри
+// { ... } не принимаются за поля прокси, а смещения в тексте не меняются.
+// '#' в значении в кавычках комментарием не считается (scanQuotes).
+func blankComments(text string) string {
+	if !strings.Contains(text, "#") {
+		return text
+	}
+	var sb strings.Builder
+	var quote byte
+	for _, line := range strings.SplitAfter(text, "\n") {
+		body := strings.TrimRight(line, "\r\n")
+		var code string
+		quote, code = scanQuotes(body, quote)
+		sb.WriteString(code)
+		sb.WriteString(strings.Repeat(" ", len(body)-len(code)))
+		sb.WriteString(line[len(body):])
+	}
+	return sb.String()
+}
+
+// entryKeyPattern находит ключи полей в тексте записи прокси
+var entryKeyPattern = regexp.MustCompile(`(?:^|[\s{,])([^\s{},:#"']+):(?:[\s,}]|$)`)
+
+// foldKey приводит ключ к виду для сравнения с -ignore-case-keys:
+// нижний регистр без дефисов и подчеркиваний (Skip-Cert-Verify,
+// skip_cert_verify и SkipCertVerify дают skipcertverify)
+func foldKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+}
+
+// sameKey сравнивает ключи: точно или, с fold, по foldKey
+func sameKey(a, b string, fold bool) bool {
+	return a == b || fold && foldKey(a) == foldKey(b)
+}
+
+// entryKey возвращает написание ключа key в тексте записи: с fold —
+// первый ключ, совпадающий по foldKey, иначе сам key
+func entryKey(text, key string, fold bool) string {
+	if !fold || hasField(text, key) {
+		return key
+	}
+	for _, m := range entryKeyPattern.FindAllStringSubmatch(text, -1) {
+		if sameKey(m[1], key, true) {
+			return m[1]
+		}
+	}
+	return key
+}
+
+// fieldValue извлекает значение поля key из текста записи прокси
+// (компактной или многострочной). После двоеточия допускается любое
+// количество пробелов и табуляций: "name: a", "name:\ta", "name:  \t a".
+func fieldValue(proxy, key string) (string, bool) {
+	re := cachedPattern(&fieldPatterns, key, func() *regexp.Regexp {
+		return regexp.MustCompile(`(?:^|[\s{,])` + regexp.QuoteMeta(key) +
+			`:[ \t]+("(?:[^"\\]|\\.)*"|'(?:[^']|'')*'|[^,}\r\n]*)`)
+	})
+
+	m := re.FindStringSubmatch(proxy)
+	if m == nil {
+		return "", false
+	}
+	return unquoteValue(m[1]), true
+}
+
+// unquoteValue убирает кавычки и комментарий в конце значения
+func unquoteValue(value string) string {
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		return strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+
+	// Комментарий начинается с '#' после пробельного символа
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
@@ -0,0 +1,61 @@
+package cli
+
+import "testing"
+
+func TestHasField(t *testing.T) {
+	cases := []struct {
+		proxy string
+		key   string
+		want  bool
+	}{
+		{"{ name: a, server: b.com, port: 443 }", "name", true},
+		{"{ username: u, server: b.com }", "name", false},
+		{"{ username: u, name: a }", "name", true},
+		{"{ supported-port: 443, server: b.com }", "port", false},
+		{"{ supported-port: 443, port: 443 }", "port", true},
+		{"{ name: a, servername: b.com }", "server", false},
+		{"{ servername: b.com, server: b.com }", "server", true},
+		{"    name: a\n    servername: b.com\n", "server", false},
+		{"    name: a\n    server: b.com\n", "server", true},
+		{"    name:\ta\n    port:\t443\n", "port", true},
+		{"{ name: a, port:\t443 }", "port", true},
+		{"{ name: a, skip-cert-verify: true }", "skip-cert-verify", true},
+		{"{ name: a, port: }", "port", true},
+		{"{ name: a, port:443 }", "port", false},
+	}
+	for _, c := range cases {
+		if got := hasField(c.proxy, c.key); got != c.want {
+			t.Errorf("hasField(%q, %q) = %v, want %v", c.proxy, c.key, got, c.want)
+		}
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	cases := []struct {
+		proxy string
+		key   string
+		want  string
+		found bool
+	}{
+		{"{ name: a, server: b.com, port: 443 }", "name", "a", true},
+		{"{ username: u, server: b.com }", "name", "", false},
+		{"{ username: u, name: a }", "name", "a", true},
+		{"{ supported-port: 8443, server: b.com }", "port", "", false},
+		{"{ supported-port: 8443, port: 443 }", "port", "443", true},
+		{"{ name: a, servername: sni.com }", "server", "", false},
+		{"{ servername: sni.com, server: b.com }", "server", "b.com", true},
+		{"    servername: sni.com\n    server: b.com\n", "server", "b.com", true},
+		{"    name:\ta\n    port:\t443\n", "name", "a", true},
+		{"    name:\ta\n    port:\t443\n", "port", "443", true},
+		{"{ name:  \t a, port: 443 }", "name", "a", true},
+		{"{ name: \"a, b\", port: 443 }", "name", "a, b", true},
+		{"{ name: 'it''s', port: 443 }", "name", "it's", true},
+		{"    name: a # comment\n", "name", "a", true},
+	}
+	for _, c := range cases {
+		got, found := fieldValue(c.proxy, c.key)
+		if got != c.want || found != c.found {
+			t.Errorf("fieldValue(%q, %q) = %q, %v, want %q, %v", c.proxy, c.key, got, found, c.want, c.found)
+		}
+	}
+}
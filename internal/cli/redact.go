@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+)
+
+// defaultRedactFields — поля, значения которых -redact скрывает по умолчанию
+var defaultRedactFields = []string{
+	"password", "uuid", "psk", "private-key", "pre-shared-key",
+	"auth", "auth-str", "obfs-password", "token",
+}
+
+// redactMask — то, что выводится вместо скрытого значения
+const redactMask = "****"
+
+// redacts проверяет, что значение поля key скрывается в выводе (-redact)
+func (o *options) redacts(key string) bool {
+	for _, field := range o.Redact {
+		if sameKey(field, key, o.IgnoreCaseKeys) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactKeyPattern — ключ поля перед значением: "key: " в начале строки,
+// после отступа, "- ", '{' или ','
+var redactKeyPattern = regexp.MustCompile(`(?m)(?:^|[\s{,])["']?([A-Za-z0-9_-]+)["']?[ \t]*:[ \t]+`)
+
+// redactText заменяет в тексте YAML значения скрываемых полей на ****.
+// Значение в кавычках заменяется целиком, без кавычек — до конца строки
+// или комментария, а внутри { } — до ',' или '}'. Без -redact текст
+// возвращается как есть.
+func (o *options) redactText(text string) string {
+	if len(o.Redact) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range redactKeyPattern.FindAllStringSubmatchIndex(text, -1) {
+		start := m[1]
+		if start < last || !o.redacts(text[m[2]:m[3]]) {
+			continue
+		}
+		// Якорь &name и тег !tag перед значением остаются видны
+		for start < len(text) && (text[start] == '&' || text[start] == '!') {
+			for start < len(text) && text[start] != ' ' && text[start] != '\t' && text[start] != '\n' {
+				start++
+			}
+			for start < len(text) && (text[start] == ' ' || text[start] == '\t') {
+				start++
+			}
+		}
+		if start == len(text) {
+			continue
+		}
+		lineStart := strings.LastIndexByte(text[:start], '\n') + 1
+		end := redactValueEnd(text, start, flowDepth(text[lineStart:start]) > 0)
+		if end == start {
+			continue
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(redactMask)
+		last = end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// redactValueEnd возвращает конец значения, которое начинается в text
+// с позиции start; flow — значение внутри { } или [ ]
+func redactValueEnd(text string, start int, flow bool) int {
+	switch quote := text[start]; quote {
+	case '"', '\'':
+		for i := start + 1; i < len(text); i++ {
+			switch {
+			case quote == '"' && text[i] == '\\':
+				i++
+			case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+				i++
+			case text[i] == quote:
+				return i + 1
+			}
+		}
+		return len(text)
+	case '|', '>', '*', '{', '[', '#', '\n', '\r':
+		// Блочный скаляр, ссылка или вложенная запись не скрываются
+		return start
+	}
+	end := start
+	for ; end < len(text); end++ {
+		c := text[end]
+		if c == '\n' || c == '\r' || flow && (c == ',' || c == '}' || c == ']') ||
+			c == '#' && (text[end-1] == ' ' || text[end-1] == '\t') {
+			break
+		}
+	}
+	return start + len(strings.TrimRight(text[start:end], " \t"))
+}
+
+// redactChanges возвращает изменения по полям (-explain) со скрытыми
+// значениями секретных полей
+func (o *options) redactChanges(changes []ProxyChange) []ProxyChange {
+	if len(o.Redact) == 0 || len(changes) == 0 {
+		return changes
+	}
+	redacted := make([]ProxyChange, len(changes))
+	for i, c := range changes {
+		if o.redacts(c.Field) {
+			if c.OldValue != "" {
+				c.OldValue = redactMask
+			}
+			if c.NewValue != "" {
+				c.NewValue = redactMask
+			}
+		}
+		redacted[i] = c
+	}
+	return redacted
+}
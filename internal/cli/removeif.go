@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// removeCondition — условие -field-remove-if: поле key (путь через точку)
+// равно value
+type removeCondition struct {
+	Key   string
+	Value string
+}
+
+// parseRemoveCondition разбирает значение -field-remove-if вида key=value
+func parseRemoveCondition(s string) (*removeCondition, error) {
+	key, value, ok := strings.Cut(s, "=")
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	if !ok || key == "" {
+		return nil, fmt.Errorf("неверное значение -field-remove-if %q, ожидается key=value", s)
+	}
+	if err := checkPath(key); err != nil {
+		return nil, fmt.Errorf("-field-remove-if %s: %w", s, err)
+	}
+	return &removeCondition{Key: key, Value: value}, nil
+}
+
+// matches проверяет условие для записи прокси. Логические значения
+// сравниваются без учета записи (true, yes, 1).
+func (c *removeCondition) matches(item *yaml.Node) bool {
+	node := placementNode(item, c.Key, false)
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return false
+	}
+	if a, ok := parseBool(node.Value); ok {
+		if b, ok := parseBool(c.Value); ok {
+			return a == b
+		}
+	}
+	return node.Value == c.Value
+}
+
+// fixRemoveIf удаляет поле (путь по -placement для типа прокси) у прокси,
+// подходящих под условие -field-remove-if. Остальные прокси не меняются.
+func fixRemoveIf(content string, opts *options) (fixResult, error) {
+	res := fixResult{Content: content}
+	doc, err := loadDocument(content)
+	if err != nil {
+		return res, fmt.Errorf("ошибка разбора YAML: %w", err)
+	}
+	seq := opts.proxyList(doc)
+	if seq == nil {
+		return res, nil
+	}
+	res.Format = "structural"
+
+	changed := false
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		res.Found++
+		name := scalarValue(item, "name")
+		status := proxyStatus{
+			Name:   name,
+			Type:   scalarValue(item, "type"),
+			Server: scalarValue(item, "server"),
+			Line:   item.Line,
+		}
+		path := opts.placement(status.Type)
+		before, _ := encodeNode(item)
+		switch {
+		case !opts.RemoveIf.matches(item) || !opts.nameSelected(name):
+			res.Filtered++
+			status.Status = statusFiltered
+		case !opts.serverSelected(status.Server):
+			res.ServerOut++
+			status.Status = statusServerOut
+		default:
+			old := removePath(item, path, opts.IgnoreCaseKeys)
+			if old == nil {
+				// Поля нет: удалять нечего
+				res.Skipped++
+				status.Status = statusSkipped
+				break
+			}
+			res.Removed++
+			status.Status = statusRemoved
+			status.Value = nodeText(old)
+			changed = true
+			if opts.Explain {
+				res.Changes = append(res.Changes, ProxyChange{
+					Name:     name,
+					Line:     item.Line,
+					Field:    path,
+					OldValue: status.Value,
+					Action:   actionRemove,
+				})
+			}
+			after, _ := encodeNode(item)
+			res.Records = append(res.Records, recordChange{
+				Name:   name,
+				Line:   item.Line,
+				Before: strings.TrimSpace(before),
+				After:  strings.TrimSpace(after),
+			})
+		}
+		res.Proxies = append(res.Proxies, status)
+	}
+
+	if changed {
+		if res.Content, err = encodeDocument(doc, content); err != nil {
+			return res, fmt.Errorf("ошибка записи YAML: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// printRemovals выводит прокси, у которых удалено поле (-field-remove-if)
+func printRemovals(proxies []proxyStatus, indent string) {
+	for _, p := range proxies {
+		if p.Status == statusRemoved {
+			sayf("%s   • %s (строка %d): было %s\n", indent, p.Name, p.Line, p.Value)
+		}
+	}
+}
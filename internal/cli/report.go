@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportEntry — строка отчета -report об одном прокси
+type reportEntry struct {
+	File   string `json:"file"`
+	Name   string `json:"name"`
+	Type   string `json:"type,omitempty"`
+	Server string `json:"server,omitempty"`
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	Value  string `json:"value,omitempty"`
+}
+
+// reportTotals — итоги отчета; считаются по всем прокси,
+// даже если в отчет попали только измененные
+type reportTotals struct {
+	Modified   int `json:"modified"`
+	AlreadyHas int `json:"already_has"`
+	Protected  int `json:"protected"`
+	Skipped    int `json:"skipped"`
+	Filtered   int `json:"filtered"`
+	Removed    int `json:"removed,omitempty"`
+	Duplicate  int `json:"duplicate,omitempty"` // удалены как повторы (-dedup-by)
+	NoField    int `json:"no_field,omitempty"`  // нет поля из -require-field
+	ServerOut  int `json:"server_filtered,omitempty"`
+	NoTLS      int `json:"no_tls,omitempty"` // прокси без TLS (-only-type-tls)
+	Total      int `json:"total"`
+}
+
+// fileNote — замечание отчета -report о файле целиком: кодировка и переводы
+// строк, которые были обнаружены и учтены при обработке
+type fileNote struct {
+	File   string `json:"file"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Виды замечаний о файле
+const (
+	noteBOM      = "bom"       // UTF-8 с BOM; BOM сохраняется в результате
+	noteEncoding = "encoding"  // UTF-16 с BOM: перекодирован для обработки и обратно
+	noteNotUTF8  = "not-utf8"  // не UTF-8 и без BOM: файл не обработан
+	noteCRLF     = "crlf"      // переводы строк CRLF
+	noteMixedEOL = "mixed-eol" // переводы строк LF и CRLF вперемешку
+	noteDecoded  = "decoded"   // тело подписки распаковано (-decode auto)
+)
+
+// proxyReport — отчет -report в формате JSON
+type proxyReport struct {
+	GeneratedAt string        `json:"generated_at"`
+	Totals      reportTotals  `json:"totals"`
+	Files       []fileNote    `json:"files,omitempty"`
+	Proxies     []reportEntry `json:"proxies"`
+}
+
+// noteInput добавляет в отчет замечания о кодировке и переводах строк
+// файла: text — текст после decodeInput, eol — значение -eol
+func (r *proxyReport) noteInput(file, text string, enc textEncoding, layers []string, eol string) {
+	if len(layers) > 0 {
+		r.Files = append(r.Files, fileNote{File: file, Kind: noteDecoded, Detail: strings.Join(layers, " → ")})
+	}
+	switch {
+	case enc.codec != nil:
+		r.Files = append(r.Files, fileNote{File: file, Kind: noteEncoding,
+			Detail: enc.Name + ": перекодирован в UTF-8 для обработки, результат — снова в " + enc.Name})
+	case enc.bom != nil:
+		r.Files = append(r.Files, fileNote{File: file, Kind: noteBOM, Detail: "BOM сохраняется в результате"})
+	}
+	crlf := strings.Count(text, "\r\n")
+	if crlf == 0 {
+		return
+	}
+	kind, detail := noteCRLF, "сохраняются"
+	if crlf < strings.Count(text, "\n") {
+		kind, detail = noteMixedEOL, "сохраняются как есть"
+	}
+	if eol == "lf" || eol == "crlf" {
+		detail = "приведены к " + eol + " (-eol)"
+	}
+	r.Files = append(r.Files, fileNote{File: file, Kind: kind, Detail: detail})
+}
+
+// noteError добавляет в отчет замечание о файле, который не удалось
+// прочитать из-за кодировки
+func (r *proxyReport) noteError(file string, err error) {
+	if errors.Is(err, errNotUTF8) {
+		r.Files = append(r.Files, fileNote{File: file, Kind: noteNotUTF8, Detail: "файл не обработан"})
+	}
+}
+
+// reportFormat определяет формат отчета по расширению файла
+func reportFormat(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return "json", nil
+	case ".csv":
+		return "csv", nil
+	default:
+		return "", fmt.Errorf("неизвестный формат отчета %q (допустимо: .json, .csv)", ext)
+	}
+}
+
+// add добавляет в отчет прокси одного файла. С -redact значение
+// секретного поля -field в отчет не попадает.
+func (r *proxyReport) add(file string, proxies []proxyStatus, opts *options) {
+	for _, p := range proxies {
+		if p.Value != "" && opts.redacts(opts.Field) {
+			p.Value = redactMask
+		}
+		switch p.Status {
+		case statusModified:
+			r.Totals.Modified++
+		case statusAlready:
+			r.Totals.AlreadyHas++
+		case statusProtected:
+			r.Totals.Protected++
+		case statusSkipped:
+			r.Totals.Skipped++
+		case statusFiltered:
+			r.Totals.Filtered++
+		case statusRemoved:
+			r.Totals.Removed++
+		case statusDuplicate:
+			r.Totals.Duplicate++
+		case statusNoField:
+			r.Totals.NoField++
+		case statusServerOut:
+			r.Totals.ServerOut++
+		case statusNoTLS:
+			r.Totals.NoTLS++
+		}
+		r.Totals.Total++
+		r.Proxies = append(r.Proxies, reportEntry{
+			File:   file,
+			Name:   p.Name,
+			Type:   p.Type,
+			Server: p.Server,
+			Line:   p.Line,
+			Status: p.Status,
+			Value:  p.Value,
+		})
+	}
+}
+
+// writeReport записывает отчет в файл path (JSON или CSV по расширению).
+// С changedOnly в список попадают только измененные прокси, итоги остаются полными.
+// JSON пишется с отступом indent, пустой — в одну строку;
+// now — время generated_at.
+func writeReport(path string, r *proxyReport, changedOnly bool, indent string, now time.Time) error {
+	format, err := reportFormat(path)
+	if err != nil {
+		return err
+	}
+
+	entries := r.Proxies
+	if changedOnly {
+		entries = nil
+		for _, e := range r.Proxies {
+			if e.Status == statusModified || e.Status == statusRemoved {
+				entries = append(entries, e)
+			}
+		}
+	}
+	if entries == nil {
+		entries = []reportEntry{}
+	}
+
+	// Замечания о файлах попадают в отчет и с changedOnly
+	var data []byte
+	if format == "json" {
+		out := *r
+		out.GeneratedAt = now.Format(time.RFC3339)
+		out.Proxies = entries
+		if indent == "" {
+			data, err = json.Marshal(out)
+		} else {
+			data, err = json.MarshalIndent(out, "", indent)
+		}
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		var buf strings.Builder
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"file", "name", "type", "server", "line", "status", "value"})
+		for _, e := range entries {
+			w.Write([]string{e.File, e.Name, e.Type, e.Server, strconv.Itoa(e.Line), e.Status, e.Value})
+		}
+		// Замечания о файле — строки без прокси: status file:<вид>
+		for _, n := range r.Files {
+			w.Write([]string{n.File, "", "", "", "0", "file:" + n.Kind, n.Detail})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		data = []byte(buf.String())
+	}
+	return writeFileMkdir(path, data, 0)
+}
@@ -0,0 +1,31 @@
+package cli
+
+import "strings"
+
+// hasRequired проверяет прокси по -require-field: has сообщает, есть ли
+// у прокси поле. Без -require-field подходит любой прокси.
+func (o *options) hasRequired(has func(key string) bool) bool {
+	if len(o.RequireFields) == 0 {
+		return true
+	}
+	for _, key := range o.RequireFields {
+		if has(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// printNoRequired выводит число и имена прокси, пропущенных без поля
+// из -require-field
+func printNoRequired(opts *options, proxies []proxyStatus, count int, indent string) {
+	if len(opts.RequireFields) == 0 {
+		return
+	}
+	sayf("%s⏭️  Пропущено без поля -require-field (%s): %d\n", indent, strings.Join(opts.RequireFields, ", "), count)
+	for _, p := range proxies {
+		if p.Status == statusNoField {
+			sayf("%s   • %s (строка %d)\n", indent, p.Name, p.Line)
+		}
+	}
+}
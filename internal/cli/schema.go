@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// isKnownField сообщает, что поле key допустимо у прокси типа proxyType
+func isKnownField(proxyType, key string) bool {
+	if key == "<<" {
+		return true
+	}
+	for _, list := range [][]string{commonFields, proxyTypes[proxyType].Fields} {
+		for _, field := range list {
+			if field == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// suggestField возвращает известное поле, похожее на key (опечатка
+// в одну-две буквы), или пустую строку
+func suggestField(proxyType, key string) string {
+	best, bestDistance := "", 3
+	for _, list := range [][]string{commonFields, proxyTypes[proxyType].Fields} {
+		for _, field := range list {
+			if d := editDistance(key, field); d < bestDistance {
+				best, bestDistance = field, d
+			}
+		}
+	}
+	return best
+}
+
+// editDistance — расстояние Левенштейна между строками
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// min3 — наименьшее из трех чисел (встроенный min появился только в Go 1.21,
+// а CI собирает на Go 1.20)
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// validateProxies проверяет ключи каждого прокси по таблице proxyTypes
+// и возвращает число проверенных прокси и предупреждения unknown-field.
+// Прокси неизвестного типа не проверяются, о них — предупреждение unknown-type.
+func validateProxies(seq *yaml.Node, offset int) (int, []warning) {
+	checked := 0
+	var warnings []warning
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		name := scalarValue(item, "name")
+		proxyType := scalarValue(item, "type")
+		if !isKnownProxyType(proxyType) {
+			warnings = append(warnings, warning{
+				Kind:    warnUnknownType,
+				Proxy:   name,
+				Line:    item.Line + offset,
+				Value:   proxyType,
+				Message: fmt.Sprintf("неизвестный тип прокси '%s', поля не проверены", proxyType),
+			})
+			continue
+		}
+		checked++
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key := item.Content[i]
+			if isKnownField(proxyType, key.Value) {
+				continue
+			}
+			msg := fmt.Sprintf("неизвестное поле '%s' у прокси типа %s", key.Value, proxyType)
+			if hint := suggestField(proxyType, key.Value); hint != "" {
+				msg += fmt.Sprintf(", возможно, %s", hint)
+			}
+			warnings = append(warnings, warning{
+				Kind:    warnUnknownField,
+				Proxy:   name,
+				Line:    key.Line + offset,
+				Value:   key.Value,
+				Message: msg,
+			})
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Line < warnings[j].Line })
+	return checked, warnings
+}
+
+// validateResult — итог -validate-only в режиме -json
+type validateResult struct {
+	Input    string    `json:"input"`
+	Checked  int       `json:"checked"`
+	Warnings []warning `json:"warnings"`
+}
+
+// runValidate проверяет поля прокси входного конфига (-validate-only)
+// и возвращает код выхода: 0 — замечаний нет, 1 — есть, 2 — ошибка чтения.
+// Файлы не записываются.
+func runValidate(inputFile string, opts *options) int {
+	seq, offset, err := loadProxyList(inputFile, opts)
+	if err != nil {
+		fmt.Fprintf(errConsole, "❌ %s: %v\n", inputFile, err)
+		return 2
+	}
+	var checked int
+	var warnings []warning
+	if seq != nil {
+		checked, warnings = validateProxies(seq, offset)
+	}
+
+	if opts.JSON {
+		if warnings == nil {
+			warnings = []warning{}
+		}
+		enc := jsonEncoder(os.Stdout, opts)
+		if err := enc.Encode(validateResult{Input: inputFile, Checked: checked, Warnings: warnings}); err != nil {
+			fmt.Fprintf(errConsole, "❌ Ошибка вывода JSON: %v\n", err)
+			return 2
+		}
+	} else {
+		sayf("🔎 ПРОВЕРКА ПОЛЕЙ ПРОКСИ: %s\n", inputFile)
+		say("══════════════════════════════════════════════")
+		if seq == nil {
+			say("Секция прокси не найдена")
+		}
+		for _, w := range warnings {
+			sayf("⚠️  %s\n", w.text())
+		}
+		if seq != nil && len(warnings) == 0 {
+			say("✅ Неизвестных полей нет")
+		}
+		say("══════════════════════════════════════════════")
+		sayf("   📄 Проверено прокси: %d, замечаний: %d\n", checked, len(warnings))
+	}
+	if len(warnings) > 0 {
+		return 1
+	}
+	return 0
+}
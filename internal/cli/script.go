@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// editScript — скрипт -emit-script: команды yq, которые повторяют
+// добавление поля без err_x509
+type editScript struct {
+	lines []string
+	edits int
+}
+
+// add добавляет команды для измененных прокси одного файла. Каждая команда
+// выбирает прокси по name и server и задает поле, только если его нет,
+// поэтому повторный запуск скрипта ничего не меняет.
+func (s *editScript) add(file string, proxies []proxyStatus, opts *options) {
+	var commands []string
+	for _, p := range proxies {
+		if p.Status != statusModified {
+			continue
+		}
+		path := opts.placement(p.Type)
+		value := p.Value
+		if strings.HasPrefix(value, "*") {
+			// Якорь yq подключить не может: поле записывается значением
+			commands = append(commands, fmt.Sprintf("# %s: в err_x509 подключен якорь %s, здесь поле задается значением", p.Name, value))
+			value = opts.Value
+		}
+		field := yqPath(path)
+		selector := fmt.Sprintf(".name == %s and .server == %s and %s == null",
+			strconv.Quote(p.Name), strconv.Quote(p.Server), field)
+		expr := fmt.Sprintf(`(.%s[] | select(%s))%s = %s`,
+			yqKey(opts.ProxiesKey), selector, field, yqValue(value, opts.FieldType))
+		commands = append(commands, "yq -i "+shellQuote(expr)+" "+shellQuote(file))
+	}
+	if len(commands) == 0 {
+		return
+	}
+	s.lines = append(s.lines, "", "# "+file)
+	s.lines = append(s.lines, commands...)
+	s.edits += len(commands)
+}
+
+// write записывает скрипт в path с правом на выполнение
+func (s *editScript) write(path string) error {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString("# Повторяет изменения err_x509 через yq v4 (https://github.com/mikefarah/yq).\n")
+	sb.WriteString("# Поле задается, только если его нет: повторный запуск ничего не меняет.\n")
+	sb.WriteString("set -eu\n")
+	sb.WriteString("command -v yq >/dev/null || { echo 'yq не найден' >&2; exit 1; }\n")
+	for _, line := range s.lines {
+		sb.WriteString(line + "\n")
+	}
+	return writeFile(path, []byte(sb.String()), 0755)
+}
+
+// yqKey возвращает ключ в записи yq: ."ключ"
+func yqKey(key string) string {
+	return strconv.Quote(key)
+}
+
+// yqPath возвращает путь из ключей через точку в записи yq: ."tls"."insecure"
+func yqPath(path string) string {
+	var sb strings.Builder
+	for _, key := range strings.Split(path, ".") {
+		sb.WriteString("." + yqKey(key))
+	}
+	return sb.String()
+}
+
+// yqValue возвращает значение поля для выражения yq с тем же типом,
+// что и в конфиге: строки в кавычках, числа и true/false как есть
+func yqValue(value, fieldType string) string {
+	var decoded interface{}
+	if err := valueNode(typedValue(value, fieldType)).Decode(&decoded); err == nil {
+		if s, ok := decoded.(string); ok {
+			return strconv.Quote(s)
+		}
+		if decoded != nil {
+			return fmt.Sprint(decoded)
+		}
+	}
+	return "null"
+}
+
+// shellQuote берет строку в одинарные кавычки для sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
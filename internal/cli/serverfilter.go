@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// serverGlobs — шаблоны адресов серверов для -include-server и
+// -exclude-server: *.example.com, hk?.example.com. Регистр не учитывается.
+type serverGlobs []string
+
+// parseServerGlobs разбирает шаблоны через запятую
+func parseServerGlobs(name, s string) (serverGlobs, error) {
+	var globs serverGlobs
+	for _, glob := range splitList(s) {
+		glob = strings.ToLower(glob)
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("Неверный шаблон -%s: %s", name, glob)
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+// matches проверяет, что сервер подходит под один из шаблонов. * не
+// переходит через '/', а через '.' переходит: *.example.com подходит и для
+// a.b.example.com, но не для example.com.
+func (g serverGlobs) matches(server string) bool {
+	server = strings.ToLower(strings.Trim(server, "[]"))
+	for _, glob := range g {
+		if ok, _ := path.Match(glob, server); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// serverFilter сообщает, что задан -include-server или -exclude-server
+func (o *options) serverFilter() bool {
+	return o.IncludeServer != nil || o.ExcludeServer != nil
+}
+
+// serverSelected проверяет server прокси по -include-server и
+// -exclude-server. Прокси без server под -include-server не подходит.
+func (o *options) serverSelected(server string) bool {
+	if o.IncludeServer != nil && !o.IncludeServer.matches(server) {
+		return false
+	}
+	return o.ExcludeServer == nil || !o.ExcludeServer.matches(server)
+}
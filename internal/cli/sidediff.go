@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// prettyDiffMinColumn — самая узкая колонка -pretty-diff; в более узком
+// окне выводится обычный unified diff
+const prettyDiffMinColumn = 30
+
+// prettyFallbackNoted — о возврате к unified diff уже сообщено
+var prettyFallbackNoted bool
+
+// renderDiff возвращает изменения от a к b для вывода пробного запуска:
+// с -pretty-diff — в две колонки, если они помещаются в окно, иначе
+// unified diff. Значения -redact скрываются. Пустая строка — изменений нет.
+func renderDiff(opts *options, fromName, toName, a, b string, records []recordChange) string {
+	a, b = opts.redactText(a), opts.redactText(b)
+	if opts.PrettyDiff {
+		width := terminalWidth()
+		if column := sideColumn(width, a, b); column >= prettyDiffMinColumn {
+			return sideBySideDiff(fromName, toName, a, b, opts.Context, width, records)
+		}
+		if !prettyFallbackNoted {
+			prettyFallbackNoted = true
+			if width == 0 {
+				say("⚠️  -pretty-diff: ширина окна неизвестна (задайте COLUMNS), показан обычный diff")
+			} else {
+				sayf("⚠️  -pretty-diff: окно слишком узкое (%d символов), показан обычный diff\n", width)
+			}
+		}
+	}
+	return unifiedDiff(fromName, toName, a, b, opts.Context)
+}
+
+// terminalWidth возвращает ширину окна: из COLUMNS, иначе у терминала
+// stdout; 0 — неизвестна
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return stdoutWidth()
+}
+
+// sideColumn возвращает ширину колонки текста для окна width
+func sideColumn(width int, a, b string) int {
+	num := numberWidth(a, b)
+	// Номер, пробел, текст слева, " | ", номер, пробел, текст справа
+	return (width - 2*(num+1) - 3) / 2
+}
+
+// numberWidth — число цифр в номере последней строки
+func numberWidth(a, b string) int {
+	n := len(splitLines(a))
+	if m := len(splitLines(b)); m > n {
+		n = m
+	}
+	return len(strconv.Itoa(n))
+}
+
+// sideBySideDiff возвращает изменения в две колонки: слева исходный
+// текст, справа результат, как sdiff. Между колонками — '|' у измененной
+// строки, '<' у удаленной и '>' у добавленной. В заголовке блока
+// перечислены прокси, записи которых в нем изменены.
+func sideBySideDiff(fromName, toName, a, b string, context, width int, records []recordChange) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	if !hasChanges(ops) {
+		return ""
+	}
+	num := numberWidth(a, b)
+	column := sideColumn(width, a, b)
+
+	var sb strings.Builder
+	row := func(aNo int, left string, mark byte, bNo int, right string) {
+		// Длинные строки переносятся внутри колонки: изменение в конце
+		// записи компактного формата должно быть видно
+		lefts, rights := sideWrap(left, column), sideWrap(right, column)
+		for k := 0; k < len(lefts) || k < len(rights); k++ {
+			l, r := strings.Repeat(" ", column), ""
+			if k < len(lefts) {
+				l = lefts[k]
+			}
+			if k < len(rights) {
+				r = rights[k]
+			}
+			if k > 0 {
+				aNo, bNo = 0, 0
+			}
+			sb.WriteString(sideNumber(aNo, num) + " " + l + " " + string(mark) + " ")
+			sb.WriteString(strings.TrimRight(sideNumber(bNo, num)+" "+r, " "))
+			sb.WriteByte('\n')
+		}
+	}
+	fmt.Fprintf(&sb, "%s %s   %s %s\n", strings.Repeat(" ", num), sideWrap(fromName, column)[0], strings.Repeat(" ", num), toName)
+
+	aLine, bLine := opLines(ops)
+	for _, h := range diffHunks(ops, context) {
+		sb.WriteString("@@")
+		if names := hunkProxies(records, aLine[h.start], aLine[h.end]); names != "" {
+			sb.WriteString(" " + names)
+		}
+		sb.WriteByte('\n')
+
+		for i := h.start; i < h.end; {
+			if ops[i].kind == ' ' {
+				row(aLine[i], ops[i].line, ' ', bLine[i], ops[i].line)
+				i++
+				continue
+			}
+			// Удаленные и добавленные строки идут парами, как замены
+			var removed, added []int
+			for ; i < h.end && ops[i].kind != ' '; i++ {
+				if ops[i].kind == '-' {
+					removed = append(removed, i)
+				} else {
+					added = append(added, i)
+				}
+			}
+			for k := 0; k < len(removed) || k < len(added); k++ {
+				switch {
+				case k < len(removed) && k < len(added):
+					row(aLine[removed[k]], ops[removed[k]].line, '|', bLine[added[k]], ops[added[k]].line)
+				case k < len(removed):
+					row(aLine[removed[k]], ops[removed[k]].line, '<', 0, "")
+				default:
+					row(0, "", '>', bLine[added[k]], ops[added[k]].line)
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+// hunkProxies перечисляет через запятую прокси, записи которых начинаются
+// в строках [from, to) исходного текста
+func hunkProxies(records []recordChange, from, to int) string {
+	var names []string
+	seen := map[string]bool{}
+	for _, r := range records {
+		if r.Line >= from && r.Line < to && !seen[r.Name] {
+			seen[r.Name] = true
+			names = append(names, r.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// sideNumber форматирует номер строки колонки; 0 — строки нет
+func sideNumber(n, width int) string {
+	if n == 0 {
+		return strings.Repeat(" ", width)
+	}
+	return fmt.Sprintf("%*d", width, n)
+}
+
+// sideWrap разбивает строку на части шириной column, последняя
+// дополняется пробелами; табуляции заменяются пробелами. Пустая строка
+// дает одну пустую часть.
+func sideWrap(line string, column int) []string {
+	line = strings.ReplaceAll(strings.TrimRight(line, "\r\n"), "\t", "    ")
+	var parts []string
+	var sb strings.Builder
+	width := 0
+	for _, r := range line {
+		w := runeWidth(r)
+		if width+w > column {
+			parts = append(parts, sb.String()+strings.Repeat(" ", column-width))
+			sb.Reset()
+			width = 0
+		}
+		sb.WriteRune(r)
+		width += w
+	}
+	return append(parts, sb.String()+strings.Repeat(" ", column-width))
+}
+
+// runeWidth — примерная ширина символа в терминале: иероглифы и эмодзи
+// занимают две клетки, модификаторы и соединители — ни одной
+func runeWidth(r rune) int {
+	switch {
+	case r == 0x200D || r >= 0xFE00 && r <= 0xFE0F || r >= 0x0300 && r <= 0x036F:
+		return 0
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return 1 // половина флага
+	case r >= 0x1100 && r <= 0x115F, r >= 0x2E80 && r <= 0xA4CF, r >= 0xAC00 && r <= 0xD7A3,
+		r >= 0xF900 && r <= 0xFAFF, r >= 0xFF00 && r <= 0xFF60, r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1FAFF, r >= 0x20000 && r <= 0x3FFFD,
+		r == 0x26A1 || r == 0x2705 || r == 0x274C:
+		return 2
+	}
+	return 1
+}
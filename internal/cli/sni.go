@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sniWarnings — предупреждения sni-mismatch (-warn-sni-mismatch): у прокси
+// с отключенной проверкой сертификата sni (или servername) не совпадает
+// с server. Без проверки такое расхождение ничем не выдает себя, а это
+// часто ошибка копирования из соседнего прокси. Прокси с IP-адресом
+// в server пропускаются: им имя в sni нужно всегда. content — конфиг,
+// который обрабатывал шаг add-skip-cert, proxies — его итоги по прокси.
+func sniWarnings(content string, proxies []proxyStatus, opts *options) []warning {
+	doc, err := loadDocument(content)
+	if err != nil {
+		return nil
+	}
+	seq := opts.proxyList(doc)
+	if seq == nil {
+		return nil
+	}
+	modified := map[int]bool{}
+	for _, p := range proxies {
+		if p.Status == statusModified && verifyDisabled(p.Value) {
+			modified[p.Line] = true
+		}
+	}
+
+	var warnings []warning
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		server := strings.Trim(scalarValue(item, "server"), "[]")
+		key, sni := "sni", scalarValue(item, "sni")
+		if sni == "" {
+			key, sni = "servername", scalarValue(item, "servername")
+		}
+		if server == "" || sni == "" || net.ParseIP(server) != nil || sameHost(server, sni) {
+			continue
+		}
+		if !modified[item.Line] {
+			existing := placementNode(item, opts.placement(scalarValue(item, "type")), opts.IgnoreCaseKeys)
+			if existing == nil || existing.Kind != yaml.ScalarNode || !verifyDisabled(existing.Value) {
+				continue
+			}
+		}
+		name := scalarValue(item, "name")
+		warnings = append(warnings, warning{
+			Kind:    warnSNIMismatch,
+			Proxy:   name,
+			Line:    item.Line,
+			Value:   sni,
+			Message: fmt.Sprintf("%s %s не совпадает с server %s, а проверка сертификата отключена: проверьте, не ошибка ли это", key, sni, server),
+		})
+	}
+	return warnings
+}
+
+// verifyDisabled сообщает, что значение поля отключает проверку
+// сертификата: true или ссылка на якорь, подключенный -use-anchor
+func verifyDisabled(value string) bool {
+	return boolSpellings[strings.ToLower(value)] == "true" || strings.HasPrefix(value, "*")
+}
+
+// sameHost сравнивает имена хостов без учета регистра и точки в конце
+func sameHost(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// Шаг -transform и значения -reconcile-sni: какое поле заполнять
+const (
+	reconcileSNIStep      = "reconcile-sni"
+	reconcileBoth         = "both"       // то, которого нет
+	reconcileToSNI        = "sni"        // только sni, из servername
+	reconcileToServername = "servername" // только servername, из sni
+)
+
+// sniConflicts — предупреждения sni-conflict для шага reconcile-sni:
+// sni и servername заданы оба и расходятся, и какое из них прочитает
+// клиент, зависит от форка
+func sniConflicts(content string, opts *options) []warning {
+	doc, err := loadDocument(content)
+	if err != nil {
+		return nil
+	}
+	seq := opts.proxyList(doc)
+	if seq == nil {
+		return nil
+	}
+	var warnings []warning
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		sni, servername := scalarValue(item, "sni"), scalarValue(item, "servername")
+		if sni == "" || servername == "" || sameHost(sni, servername) {
+			continue
+		}
+		warnings = append(warnings, warning{
+			Kind:    warnSNIConflict,
+			Proxy:   scalarValue(item, "name"),
+			Line:    item.Line,
+			Value:   sni,
+			Message: fmt.Sprintf("sni %s и servername %s расходятся, -reconcile-sni их не меняет: оставьте одно значение", sni, servername),
+		})
+	}
+	return warnings
+}
+
+// reconcilesSNI сообщает, что среди шагов -transform есть reconcile-sni
+func (o *options) reconcilesSNI() bool {
+	for _, name := range o.transforms() {
+		if name == reconcileSNIStep {
+			return true
+		}
+	}
+	return false
+}
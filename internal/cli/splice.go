@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// listEdit запоминает, где в исходном тексте списка прокси стоят записи,
+// чтобы после изменения записать заново только измененные (write)
+type listEdit struct {
+	content string
+	seq     *yaml.Node
+	inline  *yaml.Node            // ссылка, замененная копией списка (-alias-list inline)
+	outside map[*yaml.Node][]drop // комментарии вне границ записи (itemSpan)
+}
+
+// drop — комментарий узла, который остается в тексте вне записи
+type drop struct {
+	node       *yaml.Node
+	head, foot bool
+}
+
+// editList возвращает список прокси документа (proxyList) для изменения
+// вместе с listEdit. Комментарии перед записью и после ее последнего поля
+// запоминаются до изменений: после них последним может стать другое поле.
+func (o *options) editList(content string, doc *yaml.Node) (*yaml.Node, *listEdit) {
+	edit := &listEdit{content: content, outside: map[*yaml.Node][]drop{}}
+	if o.AliasList == aliasListInline {
+		if alias := sectionAlias(doc, o.proxiesKeys()); alias != nil {
+			// Копия сохраняет место ссылки в тексте: после замены у узла
+			// строка и колонка определения якоря
+			c := *alias
+			edit.inline = &c
+		}
+	}
+	edit.seq = o.proxyList(doc)
+	if edit.seq == nil {
+		return nil, edit
+	}
+	for _, item := range edit.seq.Content {
+		drops := []drop{{item, true, true}}
+		if len(item.Content) > 0 {
+			drops = append(drops, drop{item.Content[0], true, false})
+		}
+		// Комментарий после записи yaml.v3 относит к последнему ключу или
+		// значению на любой глубине вложенности
+		for node := item; len(node.Content) > 0; {
+			last := node.Content[len(node.Content)-1]
+			if node.Kind == yaml.MappingNode {
+				drops = append(drops, drop{node.Content[len(node.Content)-2], false, true})
+			}
+			drops = append(drops, drop{last, false, true})
+			node = last
+		}
+		edit.outside[item] = drops
+	}
+	return edit.seq, edit
+}
+
+// write записывает документ, в котором изменены записи items.
+// Измененные записи вставляются в исходный текст на свои места
+// (spliceItems), остальной файл остается байт в байт. С -alias-list inline
+// список вставляется на место ссылки. JSON и документы, записи которых
+// не найдены в тексте, сериализуются целиком.
+func (e *listEdit) write(doc *yaml.Node, items []*yaml.Node) (string, error) {
+	if !isJSONDocument(e.content) {
+		if e.inline != nil {
+			if out, ok := spliceAlias(e.content, e.inline, e.seq); ok {
+				return out, nil
+			}
+		} else if out, ok := e.spliceItems(items); ok {
+			return out, nil
+		}
+	}
+	return encodeDocument(doc, e.content)
+}
+
+// spliceItems заменяет в тексте каждую запись из items ее сериализацией.
+// Записи идут в порядке списка; false — запись не найдена в тексте.
+func (e *listEdit) spliceItems(items []*yaml.Node) (string, bool) {
+	content := e.content
+	lineStarts := lineOffsets(content)
+	eol := lineEnding(content)
+	var b strings.Builder
+	last := 0
+	for _, item := range items {
+		start, end, ok := itemSpan(content, lineStarts, item)
+		if !ok || start < last {
+			return content, false
+		}
+		text, err := encodeItem(item, e.outside[item])
+		if err != nil {
+			return content, false
+		}
+		if item.Style&yaml.FlowStyle != 0 && strings.HasPrefix(content[start:], "{ ") && strings.HasSuffix(content[:end], " }") &&
+			len(text) > 2 && text != "{}" {
+			// Пробелы внутри { } как в исходной записи
+			text = "{ " + text[1:len(text)-1] + " }"
+		}
+		b.WriteString(content[last:start])
+		b.WriteString(indentLines(text, strings.Repeat(" ", item.Column-1), eol))
+		last = end
+	}
+	b.WriteString(content[last:])
+	return b.String(), true
+}
+
+// itemSpan возвращает границы текста записи: от первого ключа (или от
+// "{" у записи в потоковом стиле) до конца последнего поля. Пустые строки
+// и комментарии после последнего поля в запись не входят.
+func itemSpan(content string, lineStarts []int, item *yaml.Node) (int, int, bool) {
+	start := nodeOffset(content, lineStarts, item)
+	if start < 0 || start >= len(content) {
+		return 0, 0, false
+	}
+	if item.Style&yaml.FlowStyle != 0 {
+		if content[start] != '{' {
+			return 0, 0, false
+		}
+		_, end := flowScan(content[start:])
+		if end < 0 {
+			return 0, 0, false
+		}
+		return start, start + end, true
+	}
+	if len(item.Content) == 0 || nodeOffset(content, lineStarts, item.Content[0]) != start {
+		return 0, 0, false
+	}
+	lineBody := func(i int) string {
+		next := len(content)
+		if i+1 < len(lineStarts) {
+			next = lineStarts[i+1]
+		}
+		return strings.TrimRight(content[lineStarts[i]:next], "\r\n")
+	}
+	end := lineStarts[item.Line-1] + len(lineBody(item.Line-1))
+	for i := item.Line; i < len(lineStarts); i++ {
+		body := lineBody(i)
+		trimmed := strings.TrimSpace(body)
+		if trimmed == "" {
+			continue
+		}
+		if len(body)-len(strings.TrimLeft(body, " ")) < item.Column-1 {
+			break
+		}
+		if !strings.HasPrefix(trimmed, "#") {
+			end = lineStarts[i] + len(body)
+		}
+	}
+	return start, end, true
+}
+
+// encodeItem сериализует запись без комментариев drops: они остаются
+// в тексте вне границ itemSpan
+func encodeItem(item *yaml.Node, drops []drop) (string, error) {
+	type comments struct {
+		node             *yaml.Node
+		head, line, foot string
+	}
+	var saved []comments
+	for _, d := range drops {
+		n := d.node
+		saved = append(saved, comments{n, n.HeadComment, n.LineComment, n.FootComment})
+		if d.head {
+			n.HeadComment = ""
+		}
+		if d.foot {
+			n.FootComment = ""
+		}
+	}
+	if item.Style&yaml.FlowStyle != 0 {
+		// Комментарий после "}" остается в тексте за границей записи
+		saved = append(saved, comments{item, item.HeadComment, item.LineComment, item.FootComment})
+		item.LineComment = ""
+	}
+	defer func() {
+		for i := len(saved) - 1; i >= 0; i-- {
+			n := saved[i].node
+			n.HeadComment, n.LineComment, n.FootComment = saved[i].head, saved[i].line, saved[i].foot
+		}
+	}()
+	text, err := encodeNode(item)
+	return strings.TrimRight(text, "\n"), err
+}
+
+// spliceAlias заменяет в тексте ссылку alias (proxies: *all) списком seq
+// в блочном стиле на строках после ключа или, если список в потоковом
+// стиле, на месте ссылки
+func spliceAlias(content string, alias, seq *yaml.Node) (string, bool) {
+	if seq == nil {
+		return content, false
+	}
+	start := nodeOffset(content, lineOffsets(content), alias)
+	token := "*" + alias.Value
+	if start < 0 || !strings.HasPrefix(content[start:], token) {
+		return content, false
+	}
+	text, err := encodeNode(seq)
+	if err != nil {
+		return content, false
+	}
+	text = strings.TrimRight(text, "\n")
+	eol := lineEnding(content)
+	if seq.Style&yaml.FlowStyle != 0 {
+		return content[:start] + strings.ReplaceAll(text, "\n", eol) + content[start+len(token):], true
+	}
+	lineEnd := len(content)
+	if i := strings.IndexByte(content[start:], '\n'); i >= 0 {
+		lineEnd = start + i
+	}
+	lineEnd = start + len(strings.TrimRight(content[start:lineEnd], "\r"))
+	keyEnd := len(strings.TrimRight(content[:start], " \t"))
+	return content[:keyEnd] + content[start+len(token):lineEnd] + eol +
+		indentLines("  "+text, "  ", eol) + content[lineEnd:], true
+}
+
+// indentLines сдвигает все строки текста, кроме первой, на indent
+// и соединяет их переводом строки eol
+func indentLines(text, indent, eol string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, eol)
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// runState — хэши содержимого обработанных файлов (-state). Файл, хэш
+// которого не изменился с прошлого запуска, повторно не обрабатывается.
+// Вместе с хэшем у файла хранится отпечаток параметров (optionsFingerprint):
+// запуск с другими параметрами обрабатывает файл заново.
+type runState struct {
+	Files map[string]string `json:"files"` // абсолютный путь → "sha256 содержимого sha256 параметров"
+
+	fingerprint string // отпечаток параметров этого запуска
+}
+
+// stateIgnoredFlags — флаги, которые не меняют записанный результат:
+// вывод, отчеты, пороги и выбор файлов. Все остальные входят в отпечаток.
+var stateIgnoredFlags = map[string]bool{
+	"in": true, "dir": true, "recursive": true, "max-depth": true, "newest": true,
+	"profiles": true, "profiles-key": true, "state": true, "keep-going": true, "force": true,
+	"confirm-overwrite": true, "yes": true, "y": true, "dry-run": true, "dry-run-out": true,
+	"json": true, "json-indent": true, "json-compact": true, "quiet": true, "emoji": true,
+	"locale": true, "verbose": true, "explain": true, "diff-only-changed": true,
+	"pretty-diff": true, "context": true, "report": true, "report-changed-only": true,
+	"report-diff": true, "report-diff-split": true, "bundle": true, "stats-file": true,
+	"emit-script": true, "deterministic": true, "summary-webhook": true, "webhook-timeout": true,
+	"webhook-header": true, "webhook-strict": true, "post-hook": true, "hook-strict": true,
+	"min-modified": true, "max-modified": true, "no-op-exit-zero": true,
+}
+
+// stateFileFlags — флаги с путем к файлу, содержимое которого влияет
+// на результат: в отпечаток входит и оно
+var stateFileFlags = []string{"deny-servers", "map", "error-log"}
+
+// optionsFingerprint возвращает sha256 значений флагов fs, кроме
+// stateIgnoredFlags, и содержимого файлов из stateFileFlags. Значения
+// по умолчанию тоже входят, так что -value true и запуск без -value дают
+// один отпечаток, а -value false — другой.
+func optionsFingerprint(fs *flag.FlagSet) string {
+	h := sha256.New()
+	fs.VisitAll(func(f *flag.Flag) {
+		if !stateIgnoredFlags[f.Name] {
+			fmt.Fprintf(h, "%s=%q\n", f.Name, f.Value.String())
+		}
+	})
+	for _, name := range stateFileFlags {
+		if f := fs.Lookup(name); f != nil && f.Value.String() != "" {
+			if data, err := os.ReadFile(f.Value.String()); err == nil {
+				fmt.Fprintf(h, "%s:%s\n", name, contentHash(data))
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadState читает файл состояния; отсутствующий файл — пустое состояние.
+// fingerprint — отпечаток параметров текущего запуска.
+func loadState(path, fingerprint string) (*runState, error) {
+	state := &runState{Files: map[string]string{}, fingerprint: fingerprint}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%s: неверный формат состояния: %w", path, err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]string{}
+	}
+	return state, nil
+}
+
+// save записывает файл состояния
+func (s *runState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileMkdir(path, append(data, '\n'), 0)
+}
+
+// contentHash возвращает sha256 содержимого файла в виде hex
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// stateKey — ключ файла в состоянии: абсолютный путь
+func stateKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// entry — запись файла с хэшем содержимого hash в состоянии
+func (s *runState) entry(hash string) string {
+	return hash + " " + s.fingerprint
+}
+
+// unchanged проверяет, что файл не менялся с прошлого запуска, параметры
+// те же и его результат на месте
+func (s *runState) unchanged(file batchFile, hash string) bool {
+	if s == nil || s.Files[stateKey(file.Input)] != s.entry(hash) {
+		return false
+	}
+	_, err := os.Stat(file.Output)
+	return err == nil
+}
+
+// remember сохраняет хэш обработанного файла
+func (s *runState) remember(file batchFile, hash string) {
+	if s != nil {
+		s.Files[stateKey(file.Input)] = s.entry(hash)
+	}
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOptionsFingerprint(t *testing.T) {
+	fingerprint := func(args ...string) string {
+		testOptions(t, args...)
+		return optionsFingerprint(flag.CommandLine)
+	}
+	base := fingerprint()
+	for _, args := range [][]string{{"-value", "true"}, {"-json", "-quiet"}, {"-dry-run", "-context", "5"}} {
+		if got := fingerprint(args...); got != base {
+			t.Errorf("%q меняет отпечаток, хотя результат тот же", args)
+		}
+	}
+	for _, args := range [][]string{{"-value", "false"}, {"-use-anchor"}, {"-eol", "crlf"}, {"-placement", "hysteria2=tls.insecure"}} {
+		if got := fingerprint(args...); got == base {
+			t.Errorf("%q не меняет отпечаток", args)
+		}
+	}
+
+	// Для -map в отпечаток входит содержимое файла
+	path := filepath.Join(t.TempDir(), "map.csv")
+	if err := os.WriteFile(path, []byte("name,skip\nServer1,true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before := fingerprint("-map", path)
+	if err := os.WriteFile(path, []byte("name,skip\nServer1,false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint("-map", path) == before {
+		t.Error("изменение файла -map не меняет отпечаток")
+	}
+}
+
+func TestStateReprocessesOnNewOptions(t *testing.T) {
+	dir := t.TempDir()
+	file := batchFile{Input: filepath.Join(dir, "a.yaml"), Output: filepath.Join(dir, "a.fixed.yaml")}
+	if err := os.WriteFile(file.Output, []byte("proxies: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	statePath := filepath.Join(dir, "state.json")
+	hash := contentHash([]byte("proxies: []\n"))
+
+	state, err := loadState(statePath, "value-true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.remember(file, hash)
+	if err := state.save(statePath); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		fingerprint string
+		unchanged   bool
+	}{
+		{"value-true", true},
+		{"value-false", false},
+	} {
+		state, err := loadState(statePath, c.fingerprint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := state.unchanged(file, hash); got != c.unchanged {
+			t.Errorf("отпечаток %s: unchanged = %v, want %v", c.fingerprint, got, c.unchanged)
+		}
+		if state.unchanged(file, contentHash([]byte("proxies: [x]\n"))) {
+			t.Errorf("отпечаток %s: измененный файл считается прежним", c.fingerprint)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"time"
+)
+
+// statsHeader — заголовок журнала -stats-file
+var statsHeader = []string{"timestamp", "file", "added", "total"}
+
+// appendStats дописывает в журнал -stats-file по строке на каждый файл
+// запуска. Файл создается с заголовком, если его нет или он пустой.
+func appendStats(path string, files []jsonSummary) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	if info.Size() == 0 {
+		w.Write(statsHeader)
+	}
+	timestamp := time.Now().Format(time.RFC3339)
+	for _, s := range files {
+		w.Write([]string{timestamp, s.Input, strconv.Itoa(s.Modified), strconv.Itoa(s.Total)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// maxRequestSize — наибольший размер строки запроса -batch-stdin
+const maxRequestSize = 64 << 20
+
+// stdinRequest — строка запроса -batch-stdin
+type stdinRequest struct {
+	ID      json.RawMessage `json:"id,omitempty"`
+	Content *string         `json:"content"`
+	Options stdinOptions    `json:"options"`
+}
+
+// stdinOptions — параметры, которые можно задать в запросе; незаданные
+// берутся из командной строки
+type stdinOptions struct {
+	Value       *string           `json:"value"`
+	Field       *string           `json:"field"`
+	FieldType   *string           `json:"field_type"`
+	Placement   map[string]string `json:"placement"`
+	ProxiesKey  *string           `json:"proxies_key"`
+	KeepFields  []string          `json:"keep_fields"`
+	UseAnchor   *bool             `json:"use_anchor"`
+	IncludeName *string           `json:"include_name"`
+	ExcludeName *string           `json:"exclude_name"`
+	Explain     *bool             `json:"explain"`
+	Flavor      *string           `json:"flavor"`
+}
+
+// stdinResult — строка ответа -batch-stdin
+type stdinResult struct {
+	ID         json.RawMessage `json:"id,omitempty"`
+	OK         bool            `json:"ok"`
+	Error      string          `json:"error,omitempty"`
+	Content    string          `json:"content,omitempty"`
+	Format     string          `json:"format,omitempty"`
+	Modified   int             `json:"modified"`
+	AlreadyHas int             `json:"already_has"`
+	Total      int             `json:"total"`
+	Warnings   []warning       `json:"warnings,omitempty"`
+	Changes    []ProxyChange   `json:"changes,omitempty"`
+}
+
+// apply возвращает копию параметров запуска с параметрами запроса
+func (r stdinOptions) apply(base *options) (*options, error) {
+	opts := *base
+	if r.Value != nil {
+		if opts.Value = strings.TrimSpace(*r.Value); opts.Value == "" {
+			return nil, fmt.Errorf("value не может быть пустым")
+		}
+	}
+	if r.Field != nil {
+		if err := checkPath(*r.Field); err != nil {
+			return nil, fmt.Errorf("field: %w", err)
+		}
+		opts.Field = *r.Field
+	}
+	if r.FieldType != nil {
+		opts.FieldType = *r.FieldType
+	}
+	value, err := checkFieldValue(opts.Value, opts.FieldType)
+	if err != nil {
+		return nil, fmt.Errorf("value и field_type: %w", err)
+	}
+	opts.Value = value
+	if r.Placement != nil {
+		opts.Placements = map[string]string{}
+		for proxyType, path := range r.Placement {
+			if err := checkPath(path); err != nil {
+				return nil, fmt.Errorf("placement %s: %w", proxyType, err)
+			}
+			opts.Placements[proxyType] = path
+		}
+	}
+	if r.ProxiesKey != nil {
+		if *r.ProxiesKey == "" {
+			return nil, fmt.Errorf("proxies_key не может быть пустым")
+		}
+		opts.ProxiesKey = *r.ProxiesKey
+	}
+	if r.KeepFields != nil {
+		opts.KeepFields = r.KeepFields
+	}
+	if r.UseAnchor != nil {
+		opts.UseAnchor = *r.UseAnchor
+	}
+	if r.Explain != nil {
+		opts.Explain = *r.Explain
+	}
+	if opts.IncludeName, err = requestPattern("include_name", r.IncludeName, opts.IncludeName); err != nil {
+		return nil, err
+	}
+	if opts.ExcludeName, err = requestPattern("exclude_name", r.ExcludeName, opts.ExcludeName); err != nil {
+		return nil, err
+	}
+	if r.Flavor != nil {
+		opts.Flavor = *r.Flavor
+	}
+	// Поле, размещение и значение запроса проверяются под клиента так же,
+	// как параметры командной строки
+	if err := checkFlavor(&opts); err != nil {
+		return nil, err
+	}
+	return &opts, nil
+}
+
+// requestPattern компилирует регулярное выражение из запроса: nil — оставить
+// значение из командной строки, пустая строка — отключить фильтр
+func requestPattern(name string, pattern *string, current *regexp.Regexp) (*regexp.Regexp, error) {
+	if pattern == nil {
+		return current, nil
+	}
+	if *pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(*pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return re, nil
+}
+
+// handleRequest обрабатывает одну строку запроса
+func handleRequest(line []byte, base *options) stdinResult {
+	var req stdinRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return stdinResult{Error: "неверный JSON: " + err.Error()}
+	}
+	result := stdinResult{ID: req.ID}
+	if req.Content == nil {
+		result.Error = "нет поля content"
+		return result
+	}
+	opts, err := req.Options.apply(base)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	res, err := processDocument(*req.Content, opts)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.OK = true
+	if opts.TrimTrailing {
+		res.Content = trimTrailingWhitespace(res.Content)
+	}
+	result.Content = setLineEnding(res.Content, opts.EOL)
+	result.Format = res.Format
+	result.Modified = res.Modified
+	result.AlreadyHas = res.AlreadyHas
+	result.Total = res.total()
+	result.Warnings = res.Warnings
+	result.Changes = opts.redactChanges(res.Changes)
+	return result
+}
+
+// runBatchStdin читает запросы JSON lines из in до EOF и на каждый пишет
+// строку ответа в out. Неверная или слишком длинная строка получает ответ
+// с ошибкой, чтение продолжается. Пустые строки пропускаются.
+func runBatchStdin(in io.Reader, out io.Writer, opts *options) int {
+	reader := bufio.NewReaderSize(in, 64*1024)
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	var line []byte
+	tooLong := false
+	for {
+		chunk, more, err := reader.ReadLine()
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintf(errConsole, "❌ Ошибка чтения запросов: %v\n", err)
+			return 1
+		}
+		// Остаток слишком длинной строки читается и отбрасывается
+		if !tooLong && len(line)+len(chunk) > maxRequestSize {
+			tooLong, line = true, line[:0]
+		}
+		if !tooLong {
+			line = append(line, chunk...)
+		}
+		if more {
+			continue
+		}
+		var result stdinResult
+		switch {
+		case tooLong:
+			result.Error = fmt.Sprintf("строка запроса длиннее %d МиБ", maxRequestSize>>20)
+		case len(line) == 0:
+			continue
+		default:
+			result = handleRequest(line, opts)
+		}
+		line, tooLong = line[:0], false
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(errConsole, "❌ Ошибка вывода ответа: %v\n", err)
+			return 1
+		}
+	}
+}
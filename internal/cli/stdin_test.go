@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestBatchStdin проверяет, что ошибочный запрос получает ответ с ошибкой,
+// а следующие запросы обрабатываются
+func TestBatchStdin(t *testing.T) {
+	config, _ := json.Marshal("proxies:\n  - name: a\n    type: trojan\n    server: a.example.com\n    port: 443\n")
+	request := func(id int, options string) string {
+		return `{"id": ` + strconv.Itoa(id) + `, "content": ` + string(config) + `, "options": ` + options + "}\n"
+	}
+	input := request(1, `{"value": ""}`) +
+		request(2, `{"value": "  "}`) +
+		request(3, `{"flavor": "premium", "field": "client-fingerprint", "value": "chrome"}`) +
+		request(4, `{"flavor": "unknown"}`) +
+		`{"id": 5, "content": "` + strings.Repeat("x", maxRequestSize) + "\"}\n" +
+		request(6, `{}`)
+	want := []bool{false, false, false, false, false, true}
+
+	var out bytes.Buffer
+	opts := testOptions(t)
+	if code := runBatchStdin(strings.NewReader(input), &out, opts); code != 0 {
+		t.Fatalf("код выхода %d, want 0", code)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("ответов %d, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		var result stdinResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("ответ %d: %v", i+1, err)
+		}
+		if result.OK != want[i] || (!result.OK && result.Error == "") {
+			t.Errorf("ответ %d: ok %v, ошибка %q; want ok %v", i+1, result.OK, result.Error, want[i])
+		}
+	}
+	var last stdinResult
+	_ = json.Unmarshal([]byte(lines[len(lines)-1]), &last)
+	if last.Modified != 1 || !strings.Contains(last.Content, "skip-cert-verify: true") {
+		t.Errorf("последний запрос: изменено %d, результат %q", last.Modified, last.Content)
+	}
+}
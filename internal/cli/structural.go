@@ -0,0 +1,398 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadDocument разбирает YAML в дерево узлов
+func loadDocument(content string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		// Ошибка yaml.v3 для обрезанного файла указывает на конец файла,
+		// а не на запись, которая не закрыта
+		if _, line := unterminatedEntry(content); line > 0 {
+			return nil, fmt.Errorf("%w (запись '{' на строке %d не закрыта до конца файла — файл обрезан?)", err, line)
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// lineOffsets возвращает смещения начала строк текста
+func lineOffsets(content string) []int {
+	starts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// nodeOffset переводит строку и колонку узла (колонка yaml.v3 — в
+// символах) в смещение в тексте; -1 — узла в тексте нет
+func nodeOffset(content string, lineStarts []int, n *yaml.Node) int {
+	if n.Line < 1 || n.Line > len(lineStarts) {
+		return -1
+	}
+	pos := lineStarts[n.Line-1]
+	for col := 1; col < n.Column && pos < len(content) && content[pos] != '\n'; col++ {
+		_, size := utf8.DecodeRuneInString(content[pos:])
+		pos += size
+	}
+	return pos
+}
+
+// encodeNode сериализует узел обратно в YAML с отступом в 2 пробела
+func encodeNode(node *yaml.Node) (string, error) {
+	clearMergeTags(node)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return unescapeWide(buf.String()), nil
+}
+
+// wideEscapePattern — экранированный символ вне BMP (\U0001F1FA) с обратными
+// слешами перед ним
+var wideEscapePattern = regexp.MustCompile(`(\\*)\\U([0-9A-Fa-f]{8})`)
+
+// unescapeWide возвращает на место символы вне BMP (эмодзи, флаги стран),
+// которые yaml.v3 считает непечатаемыми и записывает как \UXXXXXXXX
+// в кавычках. Кавычки остаются, имя прокси не меняется.
+func unescapeWide(text string) string {
+	if !strings.Contains(text, `\U`) {
+		return text
+	}
+	return wideEscapePattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := wideEscapePattern.FindStringSubmatch(m)
+		// Нечетное число слешей перед U — это сам слеш, а не экранирование
+		if len(sub[1])%2 != 0 {
+			return m
+		}
+		code, _ := strconv.ParseUint(sub[2], 16, 32)
+		if r := rune(code); unicode.IsGraphic(r) {
+			return sub[1] + string(r)
+		}
+		return m
+	})
+}
+
+// clearMergeTags убирает явный тег у ключей слияния: иначе yaml.v3
+// записывает их как "!!merge <<"
+func clearMergeTags(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!merge" {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		clearMergeTags(child)
+	}
+}
+
+// mappingValue возвращает значение ключа key в узле-отображении
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	return foldedValue(mapping, key, false)
+}
+
+// foldedValue — mappingValue, который с fold сравнивает ключи по foldKey
+// (-ignore-case-keys)
+func foldedValue(mapping *yaml.Node, key string, fold bool) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if sameKey(mapping.Content[i].Value, key, fold) {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalarValue возвращает значение скалярного поля key или пустую строку
+func scalarValue(mapping *yaml.Node, key string) string {
+	if v := mappingValue(mapping, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v.Value
+	}
+	return ""
+}
+
+// leadingComments возвращает блок комментариев и пустых строк в начале текста
+func leadingComments(text string) string {
+	end := 0
+	for end < len(text) {
+		next := len(text)
+		if i := strings.IndexByte(text[end:], '\n'); i >= 0 {
+			next = end + i + 1
+		}
+		line := strings.TrimSpace(text[end:next])
+		if line != "" && !strings.HasPrefix(line, "#") {
+			break
+		}
+		end = next
+	}
+	return text[:end]
+}
+
+// nodeText возвращает значение узла для вывода: скаляр как есть,
+// остальное — в виде YAML в одну строку
+func nodeText(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	text, _ := encodeNode(node)
+	return compactLines(strings.TrimSpace(text))
+}
+
+// proxiesNode находит список прокси на верхнем уровне документа
+// под одним из ключей keys. Документ, который сам является списком
+// прокси (файл proxy-provider), возвращается целиком.
+func proxiesNode(doc *yaml.Node, keys []string) *yaml.Node {
+	if seq := sectionNode(doc, keys); seq != nil {
+		return seq
+	}
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 && isProxyList(doc.Content[0]) {
+		return doc.Content[0]
+	}
+	return nil
+}
+
+// sectionNode находит список на верхнем уровне документа под одним из
+// ключей keys
+func sectionNode(doc *yaml.Node, keys []string) *yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		seq := mappingValue(doc.Content[0], key)
+		if seq != nil && seq.Kind == yaml.AliasNode && seq.Alias != nil {
+			// Список под ключом задан ссылкой на якорь: proxies: *all
+			seq = seq.Alias
+		}
+		if seq != nil && seq.Kind == yaml.SequenceNode {
+			return seq
+		}
+	}
+	return nil
+}
+
+// fixConfigStructural обрабатывает конфиг через разбор YAML. Измененные
+// прокси записываются заново на своих местах, остальной текст файла
+// не меняется (listEdit). Способ используется для операций, которым
+// нужен доступ к полям прокси (например, -keep-fields или -placement
+// с вложенным путем).
+func fixConfigStructural(content string, opts *options) (fixResult, error) {
+	res := fixResult{Content: content}
+
+	doc, err := loadDocument(content)
+	if err != nil {
+		return res, fmt.Errorf("ошибка разбора YAML: %w", err)
+	}
+	seq, edit := opts.editList(content, doc)
+	if seq == nil {
+		return res, nil
+	}
+	res.Format = "structural"
+
+	keep := map[string]bool{}
+	for _, field := range opts.KeepFields {
+		keep[field] = true
+	}
+	keep["skip-cert-verify"] = true
+	keep["<<"] = true
+
+	// Якорь для -use-anchor: подключается ключом слияния вместо поля
+	var mergeAnchor *yaml.Node
+	if opts.UseAnchor {
+		value := formatValue(typedValue(opts.Value, opts.FieldType))
+		for _, node := range fieldAnchors(doc, "skip-cert-verify") {
+			if isMergeableAnchor(node, "skip-cert-verify", value) {
+				mergeAnchor = node
+				res.Anchor = node.Anchor
+				break
+			}
+		}
+	}
+
+	var changed []*yaml.Node
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			res.Warnings = append(res.Warnings, warning{
+				Kind:    warnParseSkip,
+				Line:    item.Line,
+				Message: "элемент списка прокси не является записью и пропущен",
+			})
+			continue
+		}
+		res.Found++
+		name := scalarValue(item, "name")
+		status := proxyStatus{
+			Name:   name,
+			Type:   scalarValue(item, "type"),
+			Server: scalarValue(item, "server"),
+			Line:   item.Line,
+		}
+		path := opts.placement(status.Type)
+		before, _ := encodeNode(item)
+		itemChanged := false
+		// Комментарий # x509:value=... читаем до удаления полей: он может быть у них
+		markerVal, fromMarker := markerValue(item)
+
+		if status.Type != "" && !isKnownProxyType(status.Type) {
+			res.Warnings = append(res.Warnings, warning{
+				Kind:    warnUnknownType,
+				Proxy:   name,
+				Line:    item.Line,
+				Value:   status.Type,
+				Message: fmt.Sprintf("неизвестный тип прокси '%s'", status.Type),
+			})
+		}
+
+		// Удаляем поля, которых нет в списке -keep-fields
+		// (ключ слияния << не поле прокси и сохраняется)
+		if len(opts.KeepFields) > 0 {
+			var kept []*yaml.Node
+			for i := 0; i+1 < len(item.Content); i += 2 {
+				if key := item.Content[i].Value; keep[key] || key == strings.Split(path, ".")[0] {
+					kept = append(kept, item.Content[i], item.Content[i+1])
+				} else {
+					res.Stripped++
+					itemChanged = true
+					if opts.Explain {
+						res.Changes = append(res.Changes, ProxyChange{
+							Name:     name,
+							Line:     item.Content[i].Line,
+							Field:    item.Content[i].Value,
+							OldValue: nodeText(item.Content[i+1]),
+							Action:   actionRemove,
+						})
+					}
+				}
+			}
+			item.Content = kept
+		}
+
+		mapped, inMap := opts.ValueMap.lookup(name, status.Server)
+		// Комментарий -field-comment внутри { } разорвал бы запись
+		comment := ""
+		if seq.Style&yaml.FlowStyle == 0 && item.Style&yaml.FlowStyle == 0 && !flowOnPath(item, path) {
+			comment = opts.fieldComment()
+		}
+		status.Nested, _ = nestedVerify(item)
+		if !opts.nameSelected(name) {
+			// -include-name / -exclude-name: поле добавляется только выбранным прокси
+			res.Filtered++
+			status.Status = statusFiltered
+		} else if !opts.serverSelected(status.Server) {
+			// -include-server / -exclude-server: поле добавляется только выбранным серверам
+			res.ServerOut++
+			status.Status = statusServerOut
+		} else if !opts.tlsSelected(item, &status) {
+			// -only-type-tls: поле добавляется только прокси с TLS
+			res.NoTLS++
+			status.Status = statusNoTLS
+		} else if !opts.hasRequired(func(key string) bool {
+			return foldedValue(item, key, opts.IgnoreCaseKeys) != nil || hasMergedField(item, key)
+		}) {
+			// -require-field: поле добавляется только прокси с одним из полей
+			res.NoRequired++
+			status.Status = statusNoField
+		} else if !opts.flavorSupports(status.Type) {
+			res.Warnings = append(res.Warnings, flavorWarning(opts, name, item.Line, status.Type))
+			res.Skipped++
+			status.Status = statusSkipped
+		} else if existing := placementNode(item, path, opts.IgnoreCaseKeys); existing != nil || hasMergedField(item, path) || status.Nested != "" {
+			if existing != nil && inMap {
+				if w, conflict := mapConflict(name, existing.Line, existing.Value, mapped); conflict {
+					res.Warnings = append(res.Warnings, w)
+				}
+			}
+			res.AlreadyHas++
+			status.Status = statusAlready
+		} else if opts.DenyServers.matches(status.Server) {
+			// Серверы из -deny-servers всегда сохраняют проверку сертификата
+			res.Protected++
+			status.Status = statusProtected
+		} else if opts.ErrorHosts != nil && !opts.ErrorHosts.matches(status.Server) {
+			// С -error-log поле получают только серверы с ошибками сертификата
+			res.Skipped++
+			status.Status = statusSkipped
+		} else if mergeAnchor != nil && !fromMarker && !inMap && path == defaultPlacement {
+			item.Content = append(item.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: "<<"},
+				&yaml.Node{Kind: yaml.AliasNode, Value: mergeAnchor.Anchor, Alias: mergeAnchor, LineComment: comment})
+			res.Modified++
+			status.Status = statusModified
+			status.Value = "*" + mergeAnchor.Anchor
+			itemChanged = true
+			if opts.Explain {
+				res.Changes = append(res.Changes, insertionChange(name, item.Line, "<<: *"+mergeAnchor.Anchor))
+			}
+		} else {
+			// Комментарий у прокси важнее -map, -map — важнее -value
+			value := opts.Value
+			if fromMarker {
+				value = markerVal
+				status.FromMarker = true
+			} else if inMap {
+				value = mapped
+				status.FromMap = true
+			}
+			value, fieldType := typedValue(value, opts.FieldType)
+			node := valueNode(value, fieldType)
+			node.LineComment = comment
+			if setPlacement(item, path, node) {
+				res.Modified++
+				status.Status = statusModified
+				status.Value = value
+				itemChanged = true
+				if opts.Explain {
+					res.Changes = append(res.Changes, insertionChange(name, item.Line, path+": "+value))
+				}
+			} else {
+				res.Warnings = append(res.Warnings, warning{
+					Kind:    warnPlacement,
+					Proxy:   name,
+					Line:    item.Line,
+					Value:   path,
+					Message: fmt.Sprintf("поле %s некуда добавить: на пути есть значение, а не блок", path),
+				})
+				res.Skipped++
+				status.Status = statusSkipped
+			}
+		}
+		res.Proxies = append(res.Proxies, status)
+
+		if itemChanged {
+			changed = append(changed, item)
+			after, _ := encodeNode(item)
+			res.Records = append(res.Records, recordChange{
+				Name:   name,
+				Line:   item.Line,
+				Before: strings.TrimSpace(before),
+				After:  strings.TrimSpace(after),
+			})
+		}
+	}
+
+	if len(changed) > 0 {
+		out, err := edit.write(doc, changed)
+		if err != nil {
+			return res, fmt.Errorf("ошибка записи YAML: %w", err)
+		}
+		res.Content = out
+	}
+	return res, nil
+}
@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestHeaderCommentPreserved проверяет, что заголовок из комментариев
+// перед первым ключом остается байт в байт в обоих способах
+func TestHeaderCommentPreserved(t *testing.T) {
+	data, err := os.ReadFile("testdata/header_comment.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	end := strings.Index(content, "\nport: 7890\n") + 1
+	header := content[:end]
+	if strings.Count(header, "\n") < 20 {
+		t.Fatalf("заголовок в testdata/header_comment.yaml короче 20 строк")
+	}
+
+	opts := testOptions(t)
+	structural, err := fixConfigStructural(content, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, res := range map[string]fixResult{"structural": structural, "text": fixConfig(content, opts)} {
+		if res.Modified != 2 {
+			t.Errorf("%s: добавлено %d, want 2", name, res.Modified)
+		}
+		if !strings.HasPrefix(res.Content, header) {
+			got := res.Content
+			if len(got) > len(header) {
+				got = got[:len(header)]
+			}
+			t.Errorf("%s: заголовок изменился:\n--- было\n%s\n--- стало\n%s", name, header, got)
+		}
+	}
+}
+
+// spliceConfig — конфиг для TestStructuralSplice: отступы в 4 пробела,
+// пустые строки и комментарии вне прокси должны остаться как есть
+const spliceConfig = `# header
+dns:
+    enable: true
+
+    nameserver: [ 1.1.1.1 ]
+
+proxies:
+  - name: A
+    type: trojan
+    server: a.com
+    port: 443
+    password: p
+    # после A
+
+  - { name: B, type: ss, server: b.com, port: 8388, cipher: c } # B
+  - name: C
+    type: trojan
+    server: c.com
+    skip-cert-verify: false
+rules:
+    - MATCH,DIRECT
+`
+
+// TestStructuralSplice проверяет, что структурный способ записывает
+// заново только измененные прокси, а остальной текст не меняет
+func TestStructuralSplice(t *testing.T) {
+	flow, err := os.ReadFile("testdata/flow_sequence.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name    string
+		content string
+		args    []string
+		want    string
+	}{
+		{
+			name:    "keep-fields",
+			content: spliceConfig,
+			args:    []string{"-keep-fields", "name,type,server,port"},
+			want: `# header
+dns:
+    enable: true
+
+    nameserver: [ 1.1.1.1 ]
+
+proxies:
+  - name: A
+    type: trojan
+    server: a.com
+    port: 443
+    skip-cert-verify: true
+    # после A
+
+  - { name: B, type: ss, server: b.com, port: 8388, skip-cert-verify: true } # B
+  - name: C
+    type: trojan
+    server: c.com
+    skip-cert-verify: false
+rules:
+    - MATCH,DIRECT
+`,
+		},
+		{
+			name:    "nested placement",
+			content: spliceConfig,
+			args:    []string{"-placement", "trojan=tls.insecure"},
+			want: `# header
+dns:
+    enable: true
+
+    nameserver: [ 1.1.1.1 ]
+
+proxies:
+  - name: A
+    type: trojan
+    server: a.com
+    port: 443
+    password: p
+    tls:
+      insecure: true
+    # после A
+
+  - { name: B, type: ss, server: b.com, port: 8388, cipher: c, skip-cert-verify: true } # B
+  - name: C
+    type: trojan
+    server: c.com
+    skip-cert-verify: false
+    tls:
+      insecure: true
+rules:
+    - MATCH,DIRECT
+`,
+		},
+		{
+			name:    "crlf",
+			content: strings.ReplaceAll(spliceConfig, "\n", "\r\n"),
+			args:    []string{"-placement", "trojan=tls.insecure"},
+			want: strings.ReplaceAll(`# header
+dns:
+    enable: true
+
+    nameserver: [ 1.1.1.1 ]
+
+proxies:
+  - name: A
+    type: trojan
+    server: a.com
+    port: 443
+    password: p
+    tls:
+      insecure: true
+    # после A
+
+  - { name: B, type: ss, server: b.com, port: 8388, cipher: c, skip-cert-verify: true } # B
+  - name: C
+    type: trojan
+    server: c.com
+    skip-cert-verify: false
+    tls:
+      insecure: true
+rules:
+    - MATCH,DIRECT
+`, "\n", "\r\n"),
+		},
+		{
+			name: "alias anchor",
+			content: `x-all: &all
+    - name: A
+      type: trojan
+
+    - name: B
+      type: trojan
+      skip-cert-verify: false
+
+proxies: *all
+
+rules:
+    - MATCH,DIRECT
+`,
+			want: `x-all: &all
+    - name: A
+      type: trojan
+      skip-cert-verify: true
+
+    - name: B
+      type: trojan
+      skip-cert-verify: false
+
+proxies: *all
+
+rules:
+    - MATCH,DIRECT
+`,
+		},
+		{
+			name: "alias inline",
+			content: `x-all: &all
+    - name: A
+      type: trojan
+
+proxies: *all # список
+
+rules:
+    - MATCH,DIRECT
+`,
+			args: []string{"-alias-list", "inline"},
+			want: `x-all: &all
+    - name: A
+      type: trojan
+
+proxies: # список
+  - name: A
+    type: trojan
+    skip-cert-verify: true
+
+rules:
+    - MATCH,DIRECT
+`,
+		},
+		{
+			name:    "multiline flow",
+			content: string(flow),
+			want: strings.NewReplacer(
+				"pass}", "pass, skip-cert-verify: true}",
+				"3333}", "3333, skip-cert-verify: true}",
+			).Replace(string(flow)),
+		},
+	}
+	for _, c := range cases {
+		opts := testOptions(t, c.args...)
+		res, err := fixSkipCert(c.content, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if res.Content != c.want {
+			t.Errorf("%s:\n--- got\n%s\n--- want\n%s", c.name, res.Content, c.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+)
+
+// jsonSummary — итог обработки в режиме -json
+type jsonSummary struct {
+	Input       string         `json:"input"`
+	Output      string         `json:"output"`
+	Backup      string         `json:"backup,omitempty"`
+	Format      string         `json:"format,omitempty"`
+	Flavor      string         `json:"flavor,omitempty"` // клиент -flavor
+	Modified    int            `json:"modified"`
+	AlreadyHas  int            `json:"already_has"`
+	Total       int            `json:"total"`
+	Stripped    int            `json:"stripped,omitempty"`
+	Protected   int            `json:"protected,omitempty"`
+	Skipped     int            `json:"skipped,omitempty"`
+	Filtered    int            `json:"filtered,omitempty"`
+	NoRequired  int            `json:"no_required_field,omitempty"` // нет поля из -require-field
+	ServerOut   int            `json:"server_filtered,omitempty"`   // -include-server / -exclude-server
+	NoTLS       int            `json:"no_tls,omitempty"`            // прокси без TLS (-only-type-tls)
+	Removed     int            `json:"removed,omitempty"`
+	Duplicates  []duplicate    `json:"duplicates,omitempty"` // удалены как повторы (-dedup-by)
+	Transformed map[string]int `json:"transformed,omitempty"`
+	Matched     []string       `json:"matched,omitempty"`
+	KeyDups     []keyDuplicate `json:"duplicate_keys,omitempty"` // убраны повторы полей (-dedup-keys)
+	Bools       int            `json:"normalized_bools,omitempty"`
+	Blocks      int            `json:"markdown_blocks,omitempty"`
+	BlocksFixed int            `json:"markdown_blocks_fixed,omitempty"`
+	Warnings    []warning      `json:"warnings"`
+	Changes     []ProxyChange  `json:"changes,omitempty"`
+	Written     bool           `json:"written"`
+	Copied      bool           `json:"copied,omitempty"`
+	NotConfig   bool           `json:"not_config,omitempty"`
+	HookExit    *int           `json:"hook_exit,omitempty"` // код выхода -post-hook
+}
+
+// printJSONSummary выводит итог обработки в stdout
+func printJSONSummary(summary jsonSummary, opts *options) error {
+	if summary.Warnings == nil {
+		summary.Warnings = []warning{}
+	}
+	return jsonEncoder(os.Stdout, opts).Encode(summary)
+}
+
+// jsonIndent возвращает отступ вывода JSON по -json-indent; пустой —
+// JSON в одну строку
+func (o *options) jsonIndent() string {
+	return strings.Repeat(" ", o.JSONIndent)
+}
+
+// jsonEncoder возвращает кодировщик JSON с отступом -json-indent
+func jsonEncoder(w io.Writer, opts *options) *json.Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", opts.jsonIndent())
+	return enc
+}
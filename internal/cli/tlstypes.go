@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tlsModeTitles — описание режимов для -list-tls-types
+var tlsModeTitles = map[string]string{
+	tlsAlways:   "всегда TLS",
+	tlsOptional: "TLS, если tls: true",
+	tlsNever:    "без TLS",
+}
+
+// usesTLS решает, использует ли прокси TLS (-only-type-tls), и объясняет
+// почему. tlsValue — значение поля tls записи, если оно есть. Прокси без
+// типа и неизвестных типов считаются без TLS.
+func usesTLS(proxyType, tlsValue string, hasTLS bool) (bool, string) {
+	if proxyType == "" {
+		return false, "тип не указан"
+	}
+	switch proxyTypes[proxyType].TLS {
+	case tlsAlways:
+		return true, proxyType + " всегда использует TLS"
+	case tlsOptional:
+		if hasTLS && strings.EqualFold(tlsValue, "true") {
+			return true, proxyType + " с tls: true"
+		}
+		if hasTLS {
+			return false, proxyType + " с tls: " + tlsValue
+		}
+		return false, proxyType + " без поля tls"
+	case tlsNever:
+		return false, proxyType + " не использует TLS"
+	}
+	return false, "неизвестный тип " + proxyType
+}
+
+// tlsSelected проверяет прокси по -only-type-tls в структурном способе
+// и записывает объяснение в status.TLS. Без флага подходит любой прокси.
+func (o *options) tlsSelected(item *yaml.Node, status *proxyStatus) bool {
+	if !o.OnlyTypeTLS {
+		return true
+	}
+	tlsNode := mappingValue(item, "tls")
+	hasTLS := tlsNode != nil && tlsNode.Kind == yaml.ScalarNode
+	var tls bool
+	tls, status.TLS = usesTLS(status.Type, scalarValue(item, "tls"), hasTLS)
+	return tls
+}
+
+// printTLSTypes выводит TLS типов прокси из proxyTypes (-list-tls-types)
+func printTLSTypes() {
+	types := make([]string, 0, len(proxyTypes))
+	for proxyType := range proxyTypes {
+		types = append(types, proxyType)
+	}
+	sort.Strings(types)
+	say("🔐 Типы прокси и TLS (-only-type-tls):")
+	for _, proxyType := range types {
+		sayf("   %-10s %s\n", proxyType, tlsModeTitles[proxyTypes[proxyType].TLS])
+	}
+}
+
+// printTLSReasons выводит с -verbose, почему каждый прокси считается
+// с TLS или без (-only-type-tls)
+func printTLSReasons(opts *options, proxies []proxyStatus, indent string) {
+	if !opts.OnlyTypeTLS || !opts.Verbose {
+		return
+	}
+	for _, p := range proxies {
+		if p.TLS == "" {
+			continue
+		}
+		verdict := "обрабатывается"
+		if p.Status == statusNoTLS {
+			verdict = "пропущен"
+		}
+		sayf("%s🔐 %s: %s — %s\n", indent, p.Name, p.TLS, verdict)
+	}
+}
@@ -0,0 +1,76 @@
+package cli
+
+import "testing"
+
+func TestUsesTLS(t *testing.T) {
+	cases := []struct {
+		proxyType string
+		plain     bool // без поля tls
+		withTLS   bool // с tls: true
+	}{
+		{"direct", false, false},
+		{"http", false, true},
+		{"socks5", false, true},
+		{"ss", false, false},
+		{"ssr", false, false},
+		{"snell", false, false},
+		{"vmess", false, true},
+		{"vless", false, true},
+		{"trojan", true, true},
+		{"hysteria", true, true},
+		{"hysteria2", true, true},
+		{"tuic", true, true},
+		{"wireguard", false, false},
+		{"ssh", false, false},
+		{"mieru", false, false},
+		{"anytls", true, true},
+		{"", false, false},
+		{"trojn", false, false},
+	}
+	seen := map[string]bool{}
+	for _, c := range cases {
+		seen[c.proxyType] = true
+		if got, reason := usesTLS(c.proxyType, "", false); got != c.plain {
+			t.Errorf("usesTLS(%q) без tls = %v (%s), want %v", c.proxyType, got, reason, c.plain)
+		}
+		if got, reason := usesTLS(c.proxyType, "true", true); got != c.withTLS {
+			t.Errorf("usesTLS(%q) с tls: true = %v (%s), want %v", c.proxyType, got, reason, c.withTLS)
+		}
+		if got, reason := usesTLS(c.proxyType, "false", true); got != c.plain {
+			t.Errorf("usesTLS(%q) с tls: false = %v (%s), want %v", c.proxyType, got, reason, c.plain)
+		}
+	}
+	for proxyType := range proxyTypes {
+		if !seen[proxyType] {
+			t.Errorf("тип %q из proxyTypes не проверяется в TestUsesTLS", proxyType)
+		}
+	}
+}
+
+func TestIsKnownProxyType(t *testing.T) {
+	for proxyType := range proxyTypes {
+		if !isKnownProxyType(proxyType) {
+			t.Errorf("isKnownProxyType(%q) = false", proxyType)
+		}
+	}
+	for _, proxyType := range []string{"", "trojn", "Trojan"} {
+		if isKnownProxyType(proxyType) {
+			t.Errorf("isKnownProxyType(%q) = true", proxyType)
+		}
+	}
+}
+
+// TestProxyTypesTable проверяет строки таблицы proxyTypes: режим TLS
+// задан, а путь поля, если он есть, допустим как -placement
+func TestProxyTypesTable(t *testing.T) {
+	for name, info := range proxyTypes {
+		if tlsModeTitles[info.TLS] == "" {
+			t.Errorf("%s: неизвестный режим TLS %q", name, info.TLS)
+		}
+		if info.Placement != "" {
+			if err := checkPath(info.Placement); err != nil {
+				t.Errorf("%s: путь поля %q: %v", name, info.Placement, err)
+			}
+		}
+	}
+}
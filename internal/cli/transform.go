@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// addSkipCert — основной шаг: добавление поля всеми правилами выбора прокси
+// (-deny-servers, -error-log, -map, комментарии x509:value, -placement, ...).
+// Он выполняется движком обработки, а не как отдельный transformer.
+const addSkipCert = "add-skip-cert"
+
+// transformer изменяет одну запись прокси и возвращает изменения по полям
+// (пустой список — запись не изменилась). Имя и строку прокси в изменениях
+// заполняет runTransformer.
+type transformer func(proxy *yaml.Node, opts *options) []ProxyChange
+
+// transformers — преобразования для -transform по имени.
+// Встроенные регистрируются в init, новые — так же, одним вызовом.
+var transformers = map[string]transformer{}
+
+// registerTransformer добавляет преобразование в реестр
+func registerTransformer(name string, t transformer) {
+	if _, dup := transformers[name]; dup || name == addSkipCert {
+		panic("transformer " + name + " уже зарегистрирован")
+	}
+	transformers[name] = t
+}
+
+func init() {
+	registerTransformer("set-sni", setSNI)
+	registerTransformer("strip-insecure", stripInsecure)
+	registerTransformer("add-udp", addUDP)
+	registerTransformer(reconcileSNIStep, reconcileSNI)
+}
+
+// transformerNames возвращает имена всех преобразований по алфавиту
+func transformerNames() []string {
+	names := []string{addSkipCert}
+	for name := range transformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkTransforms проверяет, что все преобразования -transform известны
+// и указаны по одному разу
+func checkTransforms(names []string) error {
+	seen := map[string]bool{}
+	for _, name := range names {
+		if _, ok := transformers[name]; !ok && name != addSkipCert {
+			return fmt.Errorf("неизвестное преобразование %q (доступны: %s)",
+				name, strings.Join(transformerNames(), ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("преобразование %s указано дважды", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// runTransformer применяет преобразование name ко всем прокси документа.
+// Документ разбирается, измененные прокси записываются на своих местах,
+// как в структурном способе.
+func runTransformer(content, name string, opts *options, res *fixResult) (string, error) {
+	doc, err := loadDocument(content)
+	if err != nil {
+		return content, fmt.Errorf("%s: ошибка разбора YAML: %w", name, err)
+	}
+	seq, edit := opts.editList(content, doc)
+	if seq == nil {
+		return content, nil
+	}
+	if res.Transformed == nil {
+		res.Transformed = map[string]int{}
+	}
+	res.Transformed[name] = 0
+	if res.Format == "" {
+		res.Format = "structural"
+		res.Found = len(seq.Content)
+	}
+
+	var changed []*yaml.Node
+	t := transformers[name]
+	for _, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		proxyName := scalarValue(item, "name")
+		before, _ := encodeNode(item)
+		changes := t(item, opts)
+		if len(changes) == 0 {
+			continue
+		}
+		changed = append(changed, item)
+		res.Transformed[name]++
+		for i := range changes {
+			changes[i].Name = proxyName
+			changes[i].Line = item.Line
+		}
+		if opts.Explain {
+			res.Changes = append(res.Changes, changes...)
+		}
+		after, _ := encodeNode(item)
+		res.Records = append(res.Records, recordChange{
+			Name:   proxyName,
+			Line:   item.Line,
+			Before: strings.TrimSpace(before),
+			After:  strings.TrimSpace(after),
+		})
+	}
+	if len(changed) == 0 {
+		return content, nil
+	}
+	out, err := edit.write(doc, changed)
+	if err != nil {
+		return content, fmt.Errorf("%s: ошибка записи YAML: %w", name, err)
+	}
+	return out, nil
+}
+
+// printTransformed выводит, сколько прокси изменил каждый шаг -transform,
+// в порядке шагов
+func printTransformed(counts map[string]int, opts *options, indent string) {
+	for _, name := range opts.transforms() {
+		if n, ok := counts[name]; ok {
+			sayf("%s🔧 Преобразование %s: изменено прокси %d\n", indent, name, n)
+		}
+	}
+}
+
+// setSNI добавляет sni со значением server, если у прокси нет sni / servername
+// и server — имя хоста, а не IP-адрес
+func setSNI(proxy *yaml.Node, opts *options) []ProxyChange {
+	server := scalarValue(proxy, "server")
+	if server == "" || net.ParseIP(server) != nil ||
+		mappingValue(proxy, "sni") != nil || mappingValue(proxy, "servername") != nil {
+		return nil
+	}
+	proxy.Content = append(proxy.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "sni"},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: server})
+	return []ProxyChange{{Field: "sni", NewValue: server, Action: actionAdd}}
+}
+
+// reconcileSNI копирует sni в servername или наоборот, если у прокси
+// задано только одно из них (-reconcile-sni): форки читают разные поля.
+// Значения, заданные оба, не меняются, даже если расходятся: о расхождении
+// предупреждает sniConflicts.
+func reconcileSNI(proxy *yaml.Node, opts *options) []ProxyChange {
+	target := opts.ReconcileSNI
+	if target == "" {
+		target = reconcileBoth
+	}
+	for _, pair := range [][2]string{{"sni", "servername"}, {"servername", "sni"}} {
+		from, to := pair[0], pair[1]
+		if target != reconcileBoth && target != to {
+			continue
+		}
+		value := mappingValue(proxy, from)
+		if value == nil || value.Kind != yaml.ScalarNode || value.Value == "" || mappingValue(proxy, to) != nil {
+			continue
+		}
+		// Новое поле ставится сразу после исходного
+		for i := 0; i+1 < len(proxy.Content); i += 2 {
+			if proxy.Content[i].Value != from {
+				continue
+			}
+			added := []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: to},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.Value},
+			}
+			proxy.Content = append(proxy.Content[:i+2], append(added, proxy.Content[i+2:]...)...)
+			return []ProxyChange{{Field: to, NewValue: value.Value, Action: actionAdd}}
+		}
+	}
+	return nil
+}
+
+// stripInsecure удаляет поля, отключающие проверку сертификата: на верхнем
+// уровне, по пути -placement для типа прокси и во вложенных блоках
+func stripInsecure(proxy *yaml.Node, opts *options) []ProxyChange {
+	paths := append([]string{defaultPlacement, opts.placement(scalarValue(proxy, "type"))}, nestedVerifyPaths...)
+	var changes []ProxyChange
+	for _, path := range paths {
+		if old := removePath(proxy, path, opts.IgnoreCaseKeys); old != nil {
+			changes = append(changes, ProxyChange{Field: path, OldValue: nodeText(old), Action: actionRemove})
+		}
+	}
+	return changes
+}
+
+// addUDP добавляет udp: true, если поля udp нет
+func addUDP(proxy *yaml.Node, opts *options) []ProxyChange {
+	if mappingValue(proxy, "udp") != nil {
+		return nil
+	}
+	proxy.Content = append(proxy.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "udp"},
+		valueNode("true", fieldBool))
+	return []ProxyChange{{Field: "udp", NewValue: "true", Action: actionAdd}}
+}
+
+// removePath удаляет поле по пути path и возвращает его прежнее значение.
+// Блоки, оставшиеся пустыми (tls: {}), удаляются вместе с полем.
+// С fold ключи сравниваются без учета регистра (-ignore-case-keys).
+func removePath(item *yaml.Node, path string, fold bool) *yaml.Node {
+	key, rest, nested := strings.Cut(path, ".")
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		if !sameKey(item.Content[i].Value, key, fold) {
+			continue
+		}
+		value := item.Content[i+1]
+		if nested {
+			if value.Kind != yaml.MappingNode {
+				return nil
+			}
+			old := removePath(value, rest, fold)
+			if old != nil && len(value.Content) == 0 {
+				item.Content = append(item.Content[:i], item.Content[i+2:]...)
+			}
+			return old
+		}
+		item.Content = append(item.Content[:i], item.Content[i+2:]...)
+		return value
+	}
+	return nil
+}
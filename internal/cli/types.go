@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Как тип прокси использует TLS
+const (
+	tlsAlways   = "always"   // соединение всегда идет через TLS (или QUIC с TLS)
+	tlsOptional = "optional" // TLS включается полем tls: true
+	tlsNever    = "never"    // TLS нет: skip-cert-verify ни на что не влияет
+)
+
+// Наборы полей, общие для нескольких типов прокси
+var (
+	commonFields = []string{
+		"name", "type", "server", "port", "udp", "ip-version", "interface-name",
+		"routing-mark", "tfo", "mptcp", "dialer-proxy", "smux",
+	}
+	tlsFields = []string{
+		"tls", "sni", "servername", "skip-cert-verify", "fingerprint",
+		"client-fingerprint", "alpn", "ca", "ca-str", "certificate", "private-key",
+	}
+	transportFields = []string{
+		"network", "ws-opts", "h2-opts", "http-opts", "grpc-opts", "reality-opts",
+	}
+)
+
+// typeInfo — что известно о типе прокси
+type typeInfo struct {
+	TLS       string   // как тип использует TLS (-only-type-tls, -list-tls-types)
+	Fields    []string // поля верхнего уровня вдобавок к commonFields (-validate-only)
+	Placement string   // куда добавлять поле, путь через точку; пусто — defaultPlacement
+	MetaOnly  bool     // тип есть только в Mihomo, Clash Premium его не знает (-flavor premium)
+}
+
+// proxyTypes — типы прокси, которые понимают Clash / Mihomo. По этой
+// таблице проверяется тип (unknown-type) и поля (-validate-only), работают
+// -only-type-tls и -flavor premium, выбирается место поля (-placement),
+// ее выводит -list-tls-types. Новый тип — одна строка.
+var proxyTypes = map[string]typeInfo{
+	"direct":    {TLS: tlsNever, MetaOnly: true},
+	"http":      {TLS: tlsOptional, Fields: join([]string{"username", "password", "headers"}, tlsFields)},
+	"socks5":    {TLS: tlsOptional, Fields: join([]string{"username", "password"}, tlsFields)},
+	"ss":        {TLS: tlsNever, Fields: []string{"cipher", "password", "udp-over-tcp", "udp-over-tcp-version", "plugin", "plugin-opts"}}, // TLS бывает только в плагине (plugin-opts)
+	"ssr":       {TLS: tlsNever, Fields: []string{"cipher", "password", "obfs", "obfs-param", "protocol", "protocol-param"}},
+	"snell":     {TLS: tlsNever, Fields: []string{"psk", "version", "obfs-opts"}},
+	"vmess":     {TLS: tlsOptional, Fields: join([]string{"uuid", "alterId", "cipher", "packet-encoding", "global-padding", "authenticated-length"}, tlsFields, transportFields)},
+	"vless":     {TLS: tlsOptional, Fields: join([]string{"uuid", "flow", "packet-encoding", "encryption"}, tlsFields, transportFields), MetaOnly: true},
+	"trojan":    {TLS: tlsAlways, Fields: join([]string{"password", "ss-opts"}, tlsFields, transportFields)},
+	"hysteria":  {TLS: tlsAlways, Fields: join([]string{"auth", "auth-str", "obfs", "protocol", "up", "down", "up-speed", "down-speed", "recv-window-conn", "recv-window", "disable_mtu_discovery", "fast-open", "ports", "hop-interval"}, tlsFields), MetaOnly: true},
+	"hysteria2": {TLS: tlsAlways, Fields: join([]string{"password", "obfs", "obfs-password", "up", "down", "ports", "hop-interval"}, tlsFields), MetaOnly: true},
+	"tuic":      {TLS: tlsAlways, Fields: join([]string{"token", "uuid", "password", "ip", "heartbeat-interval", "disable-sni", "reduce-rtt", "request-timeout", "udp-relay-mode", "congestion-controller", "max-udp-relay-packet-size", "fast-open", "max-open-streams"}, tlsFields), MetaOnly: true},
+	"wireguard": {TLS: tlsNever, Fields: []string{"ip", "ipv6", "private-key", "public-key", "pre-shared-key", "reserved", "mtu", "remote-dns-resolve", "dns", "peers", "allowed-ips", "workers", "persistent-keepalive", "amnezia-wg-option"}},
+	"ssh":       {TLS: tlsNever, Fields: []string{"username", "password", "private-key", "private-key-passphrase", "host-key", "host-key-algorithms"}, MetaOnly: true},
+	"mieru":     {TLS: tlsNever, Fields: []string{"port-range", "transport", "username", "password", "multiplexing"}, MetaOnly: true},
+	"anytls":    {TLS: tlsAlways, Fields: join([]string{"password", "idle-session-check-interval", "idle-session-timeout", "min-idle-session"}, tlsFields), MetaOnly: true},
+}
+
+// join объединяет списки полей
+func join(lists ...[]string) []string {
+	var all []string
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// isKnownProxyType сообщает, известен ли тип прокси
+func isKnownProxyType(proxyType string) bool {
+	_, ok := proxyTypes[proxyType]
+	return ok
+}
+
+// metaOnlyTypes возвращает типы, которых нет в Clash Premium
+func metaOnlyTypes() map[string]bool {
+	types := map[string]bool{}
+	for name, t := range proxyTypes {
+		if t.MetaOnly {
+			types[name] = true
+		}
+	}
+	return types
+}
+
+// unsupportedTypes возвращает ошибку со списком неизвестных типов и прокси
+// каждого типа по предупреждениям unknown-type (-fail-on-unsupported-type)
+func unsupportedTypes(warnings []warning) error {
+	proxies := map[string][]string{}
+	for _, w := range warnings {
+		if w.Kind == warnUnknownType {
+			proxies[w.Value] = append(proxies[w.Value], w.Proxy)
+		}
+	}
+	if len(proxies) == 0 {
+		return nil
+	}
+	types := make([]string, 0, len(proxies))
+	for proxyType := range proxies {
+		types = append(types, proxyType)
+	}
+	sort.Strings(types)
+	parts := make([]string, len(types))
+	for i, proxyType := range types {
+		parts[i] = fmt.Sprintf("%s (%s)", proxyType, strings.Join(proxies[proxyType], ", "))
+	}
+	return fmt.Errorf("Неизвестные типы прокси (-fail-on-unsupported-type): %s", strings.Join(parts, "; "))
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLayers — сколько слоев base64 / gzip снимается не больше
+const maxLayers = 4
+
+// gzipMagic — первые байты потока gzip
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// unwrapInput снимает с тела подписки слои base64 и gzip (-decode=auto):
+// base64 пробуется, если текст состоит только из символов base64, затем
+// проверяется сигнатура gzip. Возвращает данные и названия снятых слоев.
+func unwrapInput(data []byte) ([]byte, []string, error) {
+	var layers []string
+	for len(layers) < maxLayers {
+		if bytes.HasPrefix(data, gzipMagic) {
+			r, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, layers, fmt.Errorf("ошибка распаковки gzip: %w", err)
+			}
+			if data, err = io.ReadAll(r); err != nil {
+				return nil, layers, fmt.Errorf("ошибка распаковки gzip: %w", err)
+			}
+			layers = append(layers, "gzip")
+			continue
+		}
+		decoded, ok := decodeBase64(data)
+		if !ok {
+			break
+		}
+		data = decoded
+		layers = append(layers, "base64")
+	}
+	return data, layers, nil
+}
+
+// decodeBase64 декодирует base64 (обычный или URL-safe, с дополнением '='
+// или без). YAML с ключами не проходит: двоеточия в base64 не бывает.
+// Результат принимается, только если это gzip или текст в UTF-8.
+func decodeBase64(data []byte) ([]byte, bool) {
+	text := strings.Join(strings.Fields(string(data)), "")
+	if text == "" {
+		return nil, false
+	}
+	for _, c := range text {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '+' || c == '/' || c == '-' || c == '_' || c == '=') {
+			return nil, false
+		}
+	}
+	for _, enc := range []*base64.Encoding{
+		base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding,
+	} {
+		decoded, err := enc.DecodeString(text)
+		if err == nil && (bytes.HasPrefix(decoded, gzipMagic) || utf8.Valid(decoded)) {
+			return decoded, true
+		}
+	}
+	return nil, false
+}
+
+// readInput снимает слои base64 / gzip с -decode=auto и декодирует текст
+func readInput(data []byte, opts *options) (string, textEncoding, []string, error) {
+	var layers []string
+	if opts.Decode == "auto" {
+		var err error
+		if data, layers, err = unwrapInput(data); err != nil {
+			return "", textEncoding{}, layers, err
+		}
+	}
+	content, enc, err := decodeInput(data)
+	return content, enc, layers, err
+}
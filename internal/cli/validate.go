@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// builtinGroupTargets — встроенные цели групп, которые не обязаны быть прокси
+var builtinGroupTargets = map[string]bool{
+	"DIRECT":      true,
+	"REJECT":      true,
+	"REJECT-DROP": true,
+	"PASS":        true,
+	"COMPATIBLE":  true,
+	"GLOBAL":      true,
+}
+
+// checkConfig ищет проблемы конфига, не мешающие обработке: повторяющиеся
+// имена прокси и ссылки групп на несуществующие прокси. Если YAML не
+// разбирается, возвращается одно предупреждение parse-error.
+func checkConfig(content string, opts *options) []warning {
+	doc, err := loadDocument(content)
+	if err != nil {
+		return []warning{{
+			Kind:    warnParseError,
+			Message: fmt.Sprintf("YAML не разбирается, проверка дубликатов и ссылок пропущена: %v", err),
+		}}
+	}
+
+	var warnings []warning
+	if alias := sectionAlias(doc, opts.proxiesKeys()); alias != nil {
+		warnings = append(warnings, aliasWarning(opts, alias))
+	}
+	names := map[string]bool{}
+	if seq := proxiesNode(doc, opts.proxiesKeys()); seq != nil {
+		for _, item := range seq.Content {
+			name := scalarValue(item, "name")
+			if name == "" {
+				continue
+			}
+			if names[name] {
+				warnings = append(warnings, warning{
+					Kind:    warnDuplicateName,
+					Proxy:   name,
+					Line:    item.Line,
+					Value:   name,
+					Message: "повторяющееся имя прокси",
+				})
+			}
+			names[name] = true
+		}
+		warnings = append(warnings, keyWarnings(seq, opts)...)
+	}
+
+	groups := groupsNode(doc, opts.groupsKeys())
+	if groups == nil {
+		return warnings
+	}
+	for _, group := range groups.Content {
+		if name := scalarValue(group, "name"); name != "" {
+			names[name] = true
+		}
+	}
+	for _, group := range groups.Content {
+		refs := mappingValue(group, "proxies")
+		if refs == nil || refs.Kind != yaml.SequenceNode {
+			continue
+		}
+		groupName := scalarValue(group, "name")
+		for _, ref := range refs.Content {
+			if ref.Kind != yaml.ScalarNode || names[ref.Value] || builtinGroupTargets[strings.ToUpper(ref.Value)] {
+				continue
+			}
+			warnings = append(warnings, warning{
+				Kind:    warnMissingRef,
+				Proxy:   groupName,
+				Line:    ref.Line,
+				Value:   ref.Value,
+				Message: fmt.Sprintf("группа ссылается на несуществующий прокси '%s'", ref.Value),
+			})
+		}
+	}
+	return warnings
+}
+
+// groupsNode находит список групп прокси на верхнем уровне документа
+func groupsNode(doc *yaml.Node, keys []string) *yaml.Node {
+	return sectionNode(doc, keys)
+}
+
+// inProxySection проверяет, что позиция pos находится в секции прокси:
+// ближайший выше ключ верхнего уровня — один из keys, а без ключей выше —
+// документ является списком прокси
+func inProxySection(content string, pos int, keys []string) bool {
+	lines := strings.Split(content[:pos], "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimRight(lines[i], "\r")
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+			continue
+		}
+		return isSectionHeader(strings.TrimSpace(line), keys)
+	}
+	return bareList(content)
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// webhookHeaderEnv — переменная окружения с заголовком для -summary-webhook,
+// если -webhook-header не задан: так токен не виден в списке процессов
+const webhookHeaderEnv = "ERR_X509_WEBHOOK_HEADER"
+
+// checkWebhook проверяет адрес -summary-webhook и заголовок "Имя: значение"
+func checkWebhook(opts *options) error {
+	u, err := url.Parse(opts.Webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("неверный адрес -summary-webhook: %s (ожидается http:// или https://)", redactURL(opts.Webhook))
+	}
+	if opts.WebhookHeader == "" {
+		opts.WebhookHeader = os.Getenv(webhookHeaderEnv)
+	}
+	if opts.WebhookHeader != "" {
+		if name, _, ok := strings.Cut(opts.WebhookHeader, ":"); !ok || strings.TrimSpace(name) == "" {
+			return errors.New("-webhook-header: ожидается \"Имя: значение\", например \"Authorization: Bearer ...\"")
+		}
+	}
+	if opts.WebhookTimeout <= 0 {
+		return fmt.Errorf("неверное значение -webhook-timeout: %s", opts.WebhookTimeout)
+	}
+	return nil
+}
+
+// postWebhook отправляет итог (схема -json) POST-запросом на -summary-webhook.
+// Ответ с кодом не 2xx считается ошибкой.
+func postWebhook(opts *options, summary interface{}) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, opts.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "err_x509")
+	if name, value, ok := strings.Cut(opts.WebhookHeader, ":"); ok {
+		req.Header.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	client := &http.Client{Timeout: opts.WebhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			// В адресе бывает токен: в сообщение он не попадает
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("сервер ответил %s", resp.Status)
+	}
+	return nil
+}
+
+// summaryWebhook отправляет итог на -summary-webhook, если он задан, и
+// выводит результат. Возвращает false, если итог не доставлен, а задан
+// -webhook-strict.
+func summaryWebhook(opts *options, summary interface{}, indent string) bool {
+	if opts.Webhook == "" {
+		return true
+	}
+	target := redactURL(opts.Webhook)
+	if err := postWebhook(opts, summary); err != nil {
+		if opts.WebhookStrict {
+			sayf("%s❌ Итог не отправлен на %s: %v\n", indent, target, err)
+			return false
+		}
+		sayf("%s⚠️  Итог не отправлен на %s: %v\n", indent, target, err)
+		return true
+	}
+	sayf("%s🪝 Итог отправлен: %s\n", indent, target)
+	return true
+}
+
+// redactURL скрывает в адресе для вывода пароль и значения параметров
+// запроса: в них обычно передают токены
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactMask
+	}
+	password := false
+	if u.User != nil {
+		_, password = u.User.Password()
+		u.User = url.User(u.User.Username())
+	}
+	var query []string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if key, _, ok := strings.Cut(pair, "="); ok {
+			query = append(query, key+"="+redactMask)
+		} else if pair != "" {
+			query = append(query, pair)
+		}
+	}
+	u.RawQuery = ""
+	out := u.String()
+	if password {
+		out = strings.Replace(out, "@", ":"+redactMask+"@", 1)
+	}
+	if len(query) > 0 {
+		out += "?" + strings.Join(query, "&")
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+)
+
+// blockScalarPattern — строка, после которой идет блочный скаляр (| или >
+// с необязательными индикаторами и комментарием): "key: |", "- >-", "|2"
+var blockScalarPattern = regexp.MustCompile(`(?:^|[\s:-])[|>][0-9+-]*[ \t]*(?:#.*)?$`)
+
+// trimTrailingWhitespace убирает пробелы и табуляции в конце строк
+// (-trim-trailing-whitespace). Строки, где они — часть значения, не меняются:
+// содержимое блочных скаляров | и > и строки внутри многострочных значений
+// в кавычках.
+func trimTrailingWhitespace(content string) string {
+	var sb strings.Builder
+	blockIndent := -1 // отступ строки с | или >, пока идет блочный скаляр
+	var quote byte    // открытая в предыдущих строках кавычка
+	for _, line := range strings.SplitAfter(content, "\n") {
+		body := strings.TrimRight(line, "\r\n")
+		eol := line[len(body):]
+		trimmed := strings.TrimLeft(body, " \t")
+		indent := len(body) - len(trimmed)
+
+		if blockIndent >= 0 {
+			if trimmed == "" || indent > blockIndent {
+				sb.WriteString(line)
+				continue
+			}
+			blockIndent = -1
+		}
+
+		endQuote, code := scanQuotes(body, quote)
+		if endQuote != 0 {
+			// Строка заканчивается внутри значения в кавычках
+			sb.WriteString(line)
+			quote = endQuote
+			continue
+		}
+		quote = 0
+		if blockScalarPattern.MatchString(strings.TrimRight(code, " \t")) {
+			blockIndent = indent
+		}
+		sb.WriteString(strings.TrimRight(body, " \t") + eol)
+	}
+	return sb.String()
+}
+
+// scanQuotes проходит строку и возвращает кавычку, открытую в ее конце
+// (0 — все закрыты), и строку без комментария. quote — кавычка, открытая
+// в предыдущих строках. Кавычка открывает значение только в начале строки
+// или после пробела, ':', ',', '[', '{' и '-': апостроф в it's
+// значением в кавычках не считается.
+func scanQuotes(line string, quote byte) (byte, string) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				if quote == '\'' && i+1 < len(line) && line[i+1] == '\'' {
+					i++ // '' внутри одинарных кавычек
+				} else {
+					quote = 0
+				}
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.IndexByte(" \t:,[{-", line[i-1]) >= 0 {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return 0, line[:i]
+		}
+	}
+	return quote, line
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package cli
+
+// stdoutWidth возвращает 0: ширина окна берется только из COLUMNS
+func stdoutWidth() int {
+	return 0
+}
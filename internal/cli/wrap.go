@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// wrapEntry переписывает компактную запись fixed в многострочном виде,
+// если строка с ней длиннее width символов (-wrap). entry — границы
+// исходной записи в content: по ним берутся отступ и остаток строки.
+// Запись, перед которой в строке есть что-то кроме отступа, не переносится.
+func wrapEntry(content string, entry proxyEntry, fixed, eol string, width int) (string, bool) {
+	lineStart := strings.LastIndexByte(content[:entry.Start], '\n') + 1
+	indent := content[lineStart:entry.Start]
+	if strings.TrimSpace(indent) != "" {
+		return fixed, false
+	}
+	lineEnd := len(content)
+	if i := strings.IndexByte(content[entry.End:], '\n'); i >= 0 {
+		lineEnd = entry.End + i
+	}
+	rest := strings.TrimSuffix(content[entry.End:lineEnd], "\r")
+	if utf8.RuneCountInString(indent+fixed+rest) <= width {
+		return fixed, false
+	}
+	// Комментарий после записи остался бы у последнего поля: не переносим
+	if strings.TrimSpace(rest) != "" {
+		return fixed, false
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.TrimPrefix(fixed, "-")), &doc); err != nil ||
+		len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fixed, false
+	}
+	mapping := doc.Content[0]
+	mapping.Style = 0
+	text, err := encodeNode(mapping)
+	if err != nil {
+		return fixed, false
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = indent + "  " + lines[i]
+	}
+	return "- " + strings.Join(lines, eol), true
+}